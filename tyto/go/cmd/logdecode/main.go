@@ -0,0 +1,151 @@
+// 将BinaryFormatter输出的二进制日志还原为TextFormatter的文本格式
+//
+// 用法:
+//
+//	logdecode [-o out.txt] [-dict file]... file...
+//
+// FileSink输出的每个文件都包含其引用的全部定义，可以单独解码
+// 旧版本或未写入文件头时，模板和元数据只在第一次出现时写入，其他文件（如err_前缀的错误日志、
+// 旋转后的新文件）中的记录可能引用本文件中没有的定义
+// 解码前会先从所有输入文件和-dict指定的文件中收集定义
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"tyto/core/logs"
+	"tyto/core/logs/binlog"
+	"tyto/core/logs/mini"
+)
+
+// 可重复的字符串参数
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func main() {
+	var (
+		outPath   string
+		dictPaths stringList
+	)
+
+	flag.StringVar(&outPath, "o", "", "output file, default is stdout")
+	flag.Var(&dictPaths, "dict", "extra binary log file to collect definitions from, can be repeated")
+	flag.Parse()
+
+	logger := mini.NewLogger()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// 收集定义
+	dict := binlog.NewDictionary()
+	for _, path := range append(dictPaths, flag.Args()...) {
+		if err := collectDictionary(path, dict); err != nil {
+			logger.Error("collect definitions from", path, "failed:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// 输出
+	out := os.Stdout
+	if outPath != "" {
+		file, err := os.Create(outPath)
+		if err != nil {
+			logger.Error("create", outPath, "failed:", err.Error())
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer := bufio.NewWriterSize(out, 64*1024)
+	defer writer.Flush()
+
+	for _, path := range flag.Args() {
+		if err := decodeFile(path, dict, writer); err != nil {
+			writer.Flush()
+			logger.Error("decode", path, "failed:", err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+func collectDictionary(path string, dict *binlog.Dictionary) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return binlog.NewDecoder(file, dict).CollectDictionary()
+}
+
+func decodeFile(path string, dict *binlog.Dictionary, out io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var (
+		entry  binlog.Entry
+		buff   bytes.Buffer
+		record = logs.NewTextRecord()
+		// 避免每条记录都创建元数据对象
+		metadataCache = make(map[*binlog.Metadata]*logs.Metadata)
+	)
+
+	formatter := logs.NewTextFormatter(logs.DEFAULT_SKIP_CALLER_COUNT, logs.DEFAULT_MAX_CALLER_COUNT)
+	decoder := binlog.NewDecoder(file, dict)
+
+	for {
+		err := decoder.Next(&entry)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		record.Level = logs.Level(entry.Level)
+		record.ReportCallerType = logs.REPORT_CALLER_TYPE_NONE
+		record.Time = entry.Time
+		record.Args = entry.Args
+		record.Metadata = nil
+		record.GoroutineId = entry.GoroutineId
+
+		if entry.Metadata != nil {
+			m, ok := metadataCache[entry.Metadata]
+			if !ok {
+				m = logs.NewMetadataWith(entry.Metadata.Service, entry.Metadata.Instance, entry.Metadata.Host, entry.Metadata.Pid)
+				metadataCache[entry.Metadata] = m
+			}
+			record.Metadata = m
+		}
+
+		buff.Reset()
+		if err := formatter.Format(&buff, record); err != nil {
+			return fmt.Errorf("format record failed: %w", err)
+		}
+		buff.WriteString(entry.Stack)
+
+		if _, err := out.Write(buff.Bytes()); err != nil {
+			return err
+		}
+	}
+}
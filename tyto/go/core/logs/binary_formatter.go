@@ -0,0 +1,249 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tyto/core/logs/binlog"
+	"tyto/core/logs/internal/stackutil"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// 最多记录的模板数量，超过后新出现的模板作为普通字符串参数写入
+const MAX_BINARY_TEMPLATE_COUNT = 4096
+
+// 二进制格式化器，用于日志量极大的场景，避免文本格式化的开销
+// 第一个参数为字符串时，视为消息模板，只在第一次出现时写入模板定义
+// 模板id由模板内容计算得出，因此不同进程、不同文件中的模板id是一致的
+// 每个新文件的文件头中会写入已有的全部定义，需要配合FileHeader使用，FileSink会自动设置
+// 可使用cmd/logdecode将其还原为TextFormatter的文本格式
+type BinaryFormatter struct {
+	skipCallerCount int32
+	maxCallerCount  int32
+	templates       sync.Map // 模板内容 -> *binaryDefinition
+	templateCount   atomic.Int32
+	metadata        sync.Map // *Metadata -> *binaryDefinition
+	pool            sync.Pool
+}
+
+// 已写入的模板或元数据定义
+type binaryDefinition struct {
+	id           uint64
+	errorDefined atomic.Bool // 是否已在ERROR_FILE_MIN_LEVEL以上的记录中写入过定义
+}
+
+func newBinaryDefinition(id uint64, level Level) *binaryDefinition {
+	def := &binaryDefinition{id: id}
+	def.errorDefined.Store(level >= ERROR_FILE_MIN_LEVEL)
+	return def
+}
+
+// 是否需要写入定义，第一次出现时需要写入
+// 错误日志文件只包含ERROR_FILE_MIN_LEVEL以上的记录，在其中第一次出现时也需要写入
+func (def *binaryDefinition) needDefine(loaded bool, level Level) bool {
+	if !loaded {
+		return true
+	}
+
+	return level >= ERROR_FILE_MIN_LEVEL && !def.errorDefined.Load() && def.errorDefined.CompareAndSwap(false, true)
+}
+
+type binaryScratch struct {
+	encoder *binlog.Encoder
+	stack   bytes.Buffer
+}
+
+func NewBinaryFormatter(skip, max int32) *BinaryFormatter {
+	return &BinaryFormatter{
+		skipCallerCount: skip,
+		maxCallerCount:  max,
+		templates:       sync.Map{},
+		templateCount:   atomic.Int32{},
+		metadata:        sync.Map{},
+		pool: sync.Pool{
+			New: func() interface{} {
+				return &binaryScratch{
+					encoder: binlog.NewEncoder(128),
+				}
+			},
+		},
+	}
+}
+
+func (f *BinaryFormatter) Format(buff *bytes.Buffer, record Record) error {
+	switch record.GetRecordType() {
+	case RECORD_TYPE_TEXT:
+		return f.formatTextRecord(buff, record)
+	default:
+		return fmt.Errorf("record type not supported, type: %d", record.GetRecordType())
+	}
+}
+
+func (f *BinaryFormatter) formatTextRecord(buff *bytes.Buffer, record Record) error {
+	r := record.(*TextRecord)
+
+	scratch := f.pool.Get().(*binaryScratch)
+	defer f.pool.Put(scratch)
+
+	enc := scratch.encoder
+
+	// 消息模板
+	args := r.Args
+	templateId := binlog.NO_TEMPLATE_ID
+	if len(args) > 0 {
+		if s, ok := args[0].(string); ok {
+			if id, ok := f.intern(buff, enc, s, r.Level); ok {
+				templateId = id
+				args = args[1:]
+			}
+		}
+	}
+
+	// 进程元数据
+	metadataId := binlog.NO_METADATA_ID
+	if r.Metadata != nil {
+		metadataId = f.internMetadata(buff, enc, r.Metadata, r.Level)
+	}
+
+	enc.BeginFrame(binlog.FRAME_TYPE_RECORD)
+
+	// 时间
+	_, offset := r.Time.Zone()
+	enc.AppendVarint(r.Time.UnixMilli())
+	enc.AppendVarint(int64(offset))
+
+	// 日志级别
+	enc.AppendByte(byte(r.Level))
+
+	// 进程元数据和goroutine id
+	enc.AppendUvarint(metadataId)
+	enc.AppendVarint(r.GoroutineId)
+
+	// 日志内容
+	enc.AppendUvarint(templateId)
+	enc.AppendUvarint(uint64(len(args)))
+	for _, arg := range args {
+		enc.AppendArg(arg)
+	}
+
+	// 调用栈
+	scratch.stack.Reset()
+	if err := f.reportCallers(&scratch.stack, record); err != nil {
+		return err
+	}
+	enc.AppendBytes(scratch.stack.Bytes())
+
+	enc.EndFrame(buff)
+	return nil
+}
+
+// 获取模板id，需要时将模板定义写入buff
+// 模板数量达到上限后，新出现的模板返回false，由调用者作为普通参数写入
+func (f *BinaryFormatter) intern(buff *bytes.Buffer, enc *binlog.Encoder, template string, level Level) (uint64, bool) {
+	v, loaded := f.templates.Load(template)
+	if !loaded {
+		// 并发时可能略微超过上限
+		if f.templateCount.Load() >= MAX_BINARY_TEMPLATE_COUNT {
+			return binlog.NO_TEMPLATE_ID, false
+		}
+
+		id := xxhash.Sum64String(template)
+		if id == binlog.NO_TEMPLATE_ID {
+			id++
+		}
+
+		v, loaded = f.templates.LoadOrStore(template, newBinaryDefinition(id, level))
+		if !loaded {
+			f.templateCount.Add(1)
+		}
+	}
+
+	def := v.(*binaryDefinition)
+	if def.needDefine(loaded, level) {
+		appendTemplate(buff, enc, def.id, template)
+	}
+
+	return def.id, true
+}
+
+// 获取元数据id，需要时将元数据定义写入buff
+func (f *BinaryFormatter) internMetadata(buff *bytes.Buffer, enc *binlog.Encoder, m *Metadata, level Level) uint64 {
+	v, loaded := f.metadata.Load(m)
+	if !loaded {
+		id := xxhash.Sum64(m.Marshal())
+		if id == binlog.NO_METADATA_ID {
+			id++
+		}
+
+		v, loaded = f.metadata.LoadOrStore(m, newBinaryDefinition(id, level))
+	}
+
+	def := v.(*binaryDefinition)
+	if def.needDefine(loaded, level) {
+		appendMetadata(buff, enc, def.id, m)
+	}
+
+	return def.id
+}
+
+// 生成新文件的文件头，包含已有的全部模板和元数据定义，使每个文件都可以单独解码
+func (f *BinaryFormatter) FileHeader(fileName string, t time.Time) []byte {
+	scratch := f.pool.Get().(*binaryScratch)
+	defer f.pool.Put(scratch)
+
+	var buff bytes.Buffer
+
+	f.templates.Range(func(key, value interface{}) bool {
+		appendTemplate(&buff, scratch.encoder, value.(*binaryDefinition).id, key.(string))
+		return true
+	})
+
+	f.metadata.Range(func(key, value interface{}) bool {
+		appendMetadata(&buff, scratch.encoder, value.(*binaryDefinition).id, key.(*Metadata))
+		return true
+	})
+
+	return buff.Bytes()
+}
+
+// 写入模板定义帧
+func appendTemplate(buff *bytes.Buffer, enc *binlog.Encoder, id uint64, template string) {
+	enc.BeginFrame(binlog.FRAME_TYPE_TEMPLATE)
+	enc.AppendUvarint(id)
+	enc.AppendString(template)
+	enc.EndFrame(buff)
+}
+
+// 写入元数据定义帧
+func appendMetadata(buff *bytes.Buffer, enc *binlog.Encoder, id uint64, m *Metadata) {
+	enc.BeginFrame(binlog.FRAME_TYPE_METADATA)
+	enc.AppendUvarint(id)
+	enc.AppendString(m.Service())
+	enc.AppendString(m.Instance())
+	enc.AppendString(m.Host())
+	enc.AppendVarint(int64(m.Pid()))
+	enc.EndFrame(buff)
+}
+
+func (f *BinaryFormatter) reportCallers(buff *bytes.Buffer, record Record) error {
+	switch record.GetReportCallerType() {
+	case REPORT_CALLER_TYPE_NONE:
+		return nil
+
+	case REPORT_CALLER_TYPE_ERROR:
+		if record.GetLevel() >= LEVEL_ERROR {
+			stackutil.Print(buff, f.skipCallerCount, f.maxCallerCount)
+		}
+
+	case REPORT_CALLER_TYPE_ALWAYS:
+		stackutil.Print(buff, f.skipCallerCount, f.maxCallerCount)
+
+	default:
+		return fmt.Errorf("report caller type not supported, type: %d", record.GetReportCallerType())
+	}
+
+	return nil
+}
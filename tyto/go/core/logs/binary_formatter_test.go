@@ -0,0 +1,136 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+	"tyto/core/logs/binlog"
+)
+
+func newBinaryTestRecord(level Level, metadata *Metadata, args ...interface{}) *TextRecord {
+	r := NewTextRecord()
+	r.Reset(level, REPORT_CALLER_TYPE_NONE, args)
+	r.Metadata = metadata
+	return r
+}
+
+func formatBinary(t *testing.T, f *BinaryFormatter, record Record) []byte {
+	t.Helper()
+
+	var buff bytes.Buffer
+	if err := f.Format(&buff, record); err != nil {
+		t.Fatal("format failed:", err)
+	}
+
+	return buff.Bytes()
+}
+
+// 单独解码一个文件，不使用其他文件中的定义
+func decodeBinary(t *testing.T, data []byte) []binlog.Entry {
+	t.Helper()
+
+	var entries []binlog.Entry
+
+	decoder := binlog.NewDecoder(bytes.NewReader(data), nil)
+	for {
+		var entry binlog.Entry
+		err := decoder.Next(&entry)
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal("decode failed:", err)
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+func checkEntry(t *testing.T, what string, entry binlog.Entry, args []interface{}) {
+	t.Helper()
+
+	if got, want := fmt.Sprint(entry.Args...), fmt.Sprint(args...); got != want {
+		t.Errorf("%s: args = %q, want %q", what, got, want)
+	}
+	if entry.Metadata == nil || entry.Metadata.Service != "svc" {
+		t.Errorf("%s: metadata = %+v, want service svc", what, entry.Metadata)
+	}
+}
+
+// 与FileSink相同的写入方式：所有记录写入普通日志文件，WARN以上同时写入错误日志文件
+// 旋转后的新文件、错误日志文件都可以单独解码
+func TestBinaryFormatterFilesDecodeAlone(t *testing.T) {
+	f := NewBinaryFormatter(DEFAULT_SKIP_CALLER_COUNT, DEFAULT_MAX_CALLER_COUNT)
+	metadata := NewMetadataWith("svc", "1", "host", 100)
+
+	var normal, errFile bytes.Buffer
+	normal.Write(f.FileHeader("app.log", time.Now()))
+	errFile.Write(f.FileHeader("err_app.log", time.Now()))
+
+	write := func(r *TextRecord) {
+		data := formatBinary(t, f, r)
+		normal.Write(data)
+		if r.Level >= ERROR_FILE_MIN_LEVEL {
+			errFile.Write(data)
+		}
+	}
+
+	// 模板和元数据第一次出现在INFO中，之后出现在WARN中
+	write(newBinaryTestRecord(LEVEL_INFO, metadata, "request %d done", 1))
+	write(newBinaryTestRecord(LEVEL_WARN, metadata, "request %d done", 2))
+	write(newBinaryTestRecord(LEVEL_WARN, metadata, "request %d done", 3))
+
+	// 旋转后的新文件
+	var rotated bytes.Buffer
+	rotated.Write(f.FileHeader("app.log.1", time.Now()))
+	rotated.Write(formatBinary(t, f, newBinaryTestRecord(LEVEL_INFO, metadata, "request %d done", 4)))
+
+	entries := decodeBinary(t, normal.Bytes())
+	if len(entries) != 3 {
+		t.Fatalf("normal file has %d entries, want 3", len(entries))
+	}
+	for i, entry := range entries {
+		checkEntry(t, "normal file", entry, []interface{}{"request %d done", i + 1})
+	}
+
+	entries = decodeBinary(t, errFile.Bytes())
+	if len(entries) != 2 {
+		t.Fatalf("error file has %d entries, want 2", len(entries))
+	}
+	for i, entry := range entries {
+		checkEntry(t, "error file", entry, []interface{}{"request %d done", i + 2})
+	}
+
+	entries = decodeBinary(t, rotated.Bytes())
+	if len(entries) != 1 {
+		t.Fatalf("rotated file has %d entries, want 1", len(entries))
+	}
+	checkEntry(t, "rotated file", entries[0], []interface{}{"request %d done", 4})
+}
+
+// 模板数量达到上限后，新的模板作为普通字符串写入
+func TestBinaryFormatterTemplateLimit(t *testing.T) {
+	f := NewBinaryFormatter(DEFAULT_SKIP_CALLER_COUNT, DEFAULT_MAX_CALLER_COUNT)
+	metadata := NewMetadataWith("svc", "1", "host", 100)
+
+	for i := 0; i < MAX_BINARY_TEMPLATE_COUNT+10; i++ {
+		formatBinary(t, f, newBinaryTestRecord(LEVEL_INFO, metadata, fmt.Sprint("dynamic ", i)))
+	}
+	if n := f.templateCount.Load(); n != MAX_BINARY_TEMPLATE_COUNT {
+		t.Fatalf("templateCount = %d, want %d", n, MAX_BINARY_TEMPLATE_COUNT)
+	}
+
+	// 超过上限的模板作为普通字符串写入，已记录的模板不受影响
+	data := f.FileHeader("app.log", time.Now())
+	data = append(data, formatBinary(t, f, newBinaryTestRecord(LEVEL_INFO, metadata, "inline %v", 1))...)
+	data = append(data, formatBinary(t, f, newBinaryTestRecord(LEVEL_INFO, metadata, "dynamic 0", 2))...)
+
+	entries := decodeBinary(t, data)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	checkEntry(t, "inline template", entries[0], []interface{}{"inline %v", 1})
+	checkEntry(t, "interned template", entries[1], []interface{}{"dynamic 0", 2})
+}
@@ -0,0 +1,342 @@
+package binlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+var (
+	ErrFrameTooLarge      = errors.New("binlog: frame too large")
+	ErrUnsupportedVersion = errors.New("binlog: unsupported version")
+	ErrCorrupted          = errors.New("binlog: corrupted frame")
+)
+
+// 进程元数据
+type Metadata struct {
+	Service  string
+	Instance string
+	Host     string
+	Pid      int32
+}
+
+// 解码后的日志记录
+type Entry struct {
+	Time        time.Time     // 记录时间，时区为写入时的时区
+	Level       int32         // 日志级别
+	Args        []interface{} // 日志参数，存在模板时，模板为第一个参数
+	Stack       string        // 调用栈，可能为空
+	Metadata    *Metadata     // 进程元数据，可能为nil
+	GoroutineId int64         // goroutine id，0表示未记录
+}
+
+// 模板和元数据的定义表
+type Dictionary struct {
+	Templates map[uint64]string    // 模板id -> 模板内容
+	Metadata  map[uint64]*Metadata // 元数据id -> 元数据
+}
+
+func NewDictionary() *Dictionary {
+	return &Dictionary{
+		Templates: make(map[uint64]string),
+		Metadata:  make(map[uint64]*Metadata),
+	}
+}
+
+// 帧解码器，非线程安全
+type Decoder struct {
+	reader  *bufio.Reader
+	dict    *Dictionary
+	version Version
+	frame   []byte
+}
+
+// dict可以预先填充，用于解码引用了其他文件中定义的记录
+// 解码过程中遇到的定义也会加入dict
+func NewDecoder(r io.Reader, dict *Dictionary) *Decoder {
+	if dict == nil {
+		dict = NewDictionary()
+	}
+
+	return &Decoder{
+		reader: bufio.NewReaderSize(r, 64*1024),
+		dict:   dict,
+		frame:  make([]byte, 0, 1024),
+	}
+}
+
+// 定义表
+func (d *Decoder) Dictionary() *Dictionary {
+	return d.dict
+}
+
+// 读取下一条日志记录，定义帧会被自动处理
+// 读取完毕返回io.EOF
+func (d *Decoder) Next(entry *Entry) error {
+	for {
+		frameType, payload, err := d.readFrame()
+		if err != nil {
+			return err
+		}
+
+		switch frameType {
+		case FRAME_TYPE_TEMPLATE:
+			if err := d.decodeTemplate(payload); err != nil {
+				return err
+			}
+
+		case FRAME_TYPE_RECORD:
+			return d.decodeRecord(payload, entry)
+
+		case FRAME_TYPE_METADATA:
+			if err := d.decodeMetadata(payload); err != nil {
+				return err
+			}
+
+		default:
+			// 同一版本内新增的帧类型，跳过
+		}
+	}
+}
+
+// 只读取定义，跳过所有日志记录
+// 通常用于在解码前，从多个文件中收集定义
+func (d *Decoder) CollectDictionary() error {
+	for {
+		frameType, payload, err := d.readFrame()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch frameType {
+		case FRAME_TYPE_TEMPLATE:
+			err = d.decodeTemplate(payload)
+		case FRAME_TYPE_METADATA:
+			err = d.decodeMetadata(payload)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *Decoder) readFrame() (FrameType, []byte, error) {
+	size, err := binary.ReadUvarint(d.reader)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if size > MAX_FRAME_SIZE {
+		return 0, nil, ErrFrameTooLarge
+	}
+
+	if size < 2 {
+		return 0, nil, ErrCorrupted
+	}
+
+	if uint64(cap(d.frame)) < size {
+		d.frame = make([]byte, size)
+	}
+	d.frame = d.frame[:size]
+
+	if _, err := io.ReadFull(d.reader, d.frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+
+	version := Version(d.frame[0])
+	if version < VERSION_1 || version > CURRENT_VERSION {
+		return 0, nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	d.version = version
+
+	return FrameType(d.frame[1]), d.frame[2:], nil
+}
+
+func (d *Decoder) decodeTemplate(payload []byte) error {
+	r := reader{data: payload}
+
+	id := r.uvarint()
+	text := r.string()
+	if r.err != nil {
+		return r.err
+	}
+
+	d.dict.Templates[id] = text
+	return nil
+}
+
+func (d *Decoder) decodeMetadata(payload []byte) error {
+	r := reader{data: payload}
+
+	id := r.uvarint()
+	m := &Metadata{
+		Service:  r.string(),
+		Instance: r.string(),
+		Host:     r.string(),
+		Pid:      int32(r.varint()),
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	d.dict.Metadata[id] = m
+	return nil
+}
+
+func (d *Decoder) decodeRecord(payload []byte, entry *Entry) error {
+	r := reader{data: payload}
+
+	millis := r.varint()
+	offset := r.varint()
+	level := r.byte()
+	metadataId := NO_METADATA_ID
+	goroutineId := int64(0)
+	if d.version >= VERSION_2 {
+		metadataId = r.uvarint()
+		goroutineId = r.varint()
+	}
+	templateId := r.uvarint()
+	argc := r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+
+	if argc > uint64(len(payload)) {
+		return ErrCorrupted
+	}
+
+	entry.Time = time.UnixMilli(millis).In(time.FixedZone("", int(offset)))
+	entry.Level = int32(level)
+	entry.Args = entry.Args[:0]
+	entry.Stack = ""
+	entry.Metadata = nil
+	entry.GoroutineId = goroutineId
+
+	if metadataId != NO_METADATA_ID {
+		m, ok := d.dict.Metadata[metadataId]
+		if !ok {
+			m = &Metadata{Service: fmt.Sprintf("<unknown metadata %d>", metadataId)}
+		}
+		entry.Metadata = m
+	}
+
+	if templateId != NO_TEMPLATE_ID {
+		text, ok := d.dict.Templates[templateId]
+		if !ok {
+			text = fmt.Sprintf("<unknown template %d>", templateId)
+		}
+		entry.Args = append(entry.Args, text)
+	}
+
+	for i := uint64(0); i < argc; i++ {
+		entry.Args = append(entry.Args, r.arg())
+	}
+
+	entry.Stack = r.string()
+
+	return r.err
+}
+
+// 帧内容读取器，出错后所有读取都返回零值
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) fail() {
+	if r.err == nil {
+		r.err = ErrCorrupted
+	}
+	r.data = nil
+}
+
+func (r *reader) byte() byte {
+	if len(r.data) < 1 {
+		r.fail()
+		return 0
+	}
+
+	v := r.data[0]
+	r.data = r.data[1:]
+	return v
+}
+
+func (r *reader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *reader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *reader) string() string {
+	size := r.uvarint()
+	if size > uint64(len(r.data)) {
+		r.fail()
+		return ""
+	}
+
+	s := string(r.data[:size])
+	r.data = r.data[size:]
+	return s
+}
+
+func (r *reader) fixed(size int) []byte {
+	if len(r.data) < size {
+		r.fail()
+		return make([]byte, size)
+	}
+
+	bs := r.data[:size]
+	r.data = r.data[size:]
+	return bs
+}
+
+func (r *reader) arg() interface{} {
+	switch ArgType(r.byte()) {
+	case ARG_TYPE_NIL:
+		return nil
+	case ARG_TYPE_STRING:
+		return r.string()
+	case ARG_TYPE_INT:
+		return r.varint()
+	case ARG_TYPE_UINT:
+		return r.uvarint()
+	case ARG_TYPE_FLOAT32:
+		return math.Float32frombits(binary.LittleEndian.Uint32(r.fixed(4)))
+	case ARG_TYPE_FLOAT64:
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.fixed(8)))
+	case ARG_TYPE_TRUE:
+		return true
+	case ARG_TYPE_FALSE:
+		return false
+	default:
+		r.fail()
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package binlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// 帧编码器，非线程安全，可复用
+type Encoder struct {
+	payload []byte
+}
+
+func NewEncoder(size int) *Encoder {
+	return &Encoder{
+		payload: make([]byte, 0, size),
+	}
+}
+
+// 开始一个新的帧，之前未结束的帧会被丢弃
+func (e *Encoder) BeginFrame(frameType FrameType) {
+	e.payload = append(e.payload[:0], byte(CURRENT_VERSION), byte(frameType))
+}
+
+// 结束当前帧，将帧长度和帧内容写入buff
+func (e *Encoder) EndFrame(buff *bytes.Buffer) {
+	var head [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(head[:], uint64(len(e.payload)))
+
+	buff.Write(head[:n])
+	buff.Write(e.payload)
+}
+
+func (e *Encoder) AppendByte(v byte) {
+	e.payload = append(e.payload, v)
+}
+
+func (e *Encoder) AppendUvarint(v uint64) {
+	e.payload = binary.AppendUvarint(e.payload, v)
+}
+
+func (e *Encoder) AppendVarint(v int64) {
+	e.payload = binary.AppendVarint(e.payload, v)
+}
+
+func (e *Encoder) AppendString(s string) {
+	e.payload = binary.AppendUvarint(e.payload, uint64(len(s)))
+	e.payload = append(e.payload, s...)
+}
+
+func (e *Encoder) AppendBytes(bs []byte) {
+	e.payload = binary.AppendUvarint(e.payload, uint64(len(bs)))
+	e.payload = append(e.payload, bs...)
+}
+
+// 写入带类型的参数
+// 解码后以%v格式化的结果与原参数一致，无法识别的类型会先格式化为字符串
+func (e *Encoder) AppendArg(arg interface{}) {
+	switch v := arg.(type) {
+	case nil:
+		e.AppendByte(byte(ARG_TYPE_NIL))
+	case string:
+		e.AppendByte(byte(ARG_TYPE_STRING))
+		e.AppendString(v)
+	case bool:
+		if v {
+			e.AppendByte(byte(ARG_TYPE_TRUE))
+		} else {
+			e.AppendByte(byte(ARG_TYPE_FALSE))
+		}
+	case int:
+		e.appendInt(int64(v))
+	case int8:
+		e.appendInt(int64(v))
+	case int16:
+		e.appendInt(int64(v))
+	case int32:
+		e.appendInt(int64(v))
+	case int64:
+		e.appendInt(v)
+	case uint:
+		e.appendUint(uint64(v))
+	case uint8:
+		e.appendUint(uint64(v))
+	case uint16:
+		e.appendUint(uint64(v))
+	case uint32:
+		e.appendUint(uint64(v))
+	case uint64:
+		e.appendUint(v)
+	case uintptr:
+		e.appendUint(uint64(v))
+	case float32:
+		e.AppendByte(byte(ARG_TYPE_FLOAT32))
+		e.payload = binary.LittleEndian.AppendUint32(e.payload, math.Float32bits(v))
+	case float64:
+		e.AppendByte(byte(ARG_TYPE_FLOAT64))
+		e.payload = binary.LittleEndian.AppendUint64(e.payload, math.Float64bits(v))
+	default:
+		e.AppendByte(byte(ARG_TYPE_STRING))
+		e.AppendString(fmt.Sprint(v))
+	}
+}
+
+func (e *Encoder) appendInt(v int64) {
+	e.AppendByte(byte(ARG_TYPE_INT))
+	e.AppendVarint(v)
+}
+
+func (e *Encoder) appendUint(v uint64) {
+	e.AppendByte(byte(ARG_TYPE_UINT))
+	e.AppendUvarint(v)
+}
@@ -0,0 +1,57 @@
+package binlog
+
+// 二进制日志格式
+//
+// 文件由若干帧首尾相连组成，每一帧的格式为:
+//
+//	uvarint 帧长度(不含自身) | byte 版本号 | byte 帧类型 | 帧内容
+//
+// 日志记录帧的内容为:
+//
+//	VERSION_1: varint 毫秒时间戳 | varint 时区偏移秒数 | byte 级别 |
+//	           uvarint 模板id | uvarint 参数个数 | 参数... | string 调用栈
+//	VERSION_2: 在级别之后增加 uvarint 元数据id | varint goroutine id
+//
+// 每一帧都带有版本号，因此文件被旋转、截断或由不同版本的程序追加写入时，
+// 仍可逐帧解码
+
+// 格式版本
+type Version uint8
+
+const (
+	VERSION_1       Version = 1         // 第一版
+	VERSION_2       Version = 2         // 日志记录增加元数据id和goroutine id
+	CURRENT_VERSION Version = VERSION_2 // 当前写入使用的版本
+)
+
+// 帧类型
+type FrameType uint8
+
+const (
+	FRAME_TYPE_TEMPLATE FrameType = 1 // 消息模板定义
+	FRAME_TYPE_RECORD   FrameType = 2 // 日志记录
+	FRAME_TYPE_METADATA FrameType = 3 // 进程元数据定义，VERSION_2新增
+)
+
+// 参数类型
+type ArgType uint8
+
+const (
+	ARG_TYPE_NIL     ArgType = 0 // nil
+	ARG_TYPE_STRING  ArgType = 1 // 字符串，无法识别的类型也会格式化为字符串
+	ARG_TYPE_INT     ArgType = 2 // 有符号整数，zigzag varint
+	ARG_TYPE_UINT    ArgType = 3 // 无符号整数，uvarint
+	ARG_TYPE_FLOAT32 ArgType = 4 // 单精度浮点数，4字节小端
+	ARG_TYPE_FLOAT64 ArgType = 5 // 双精度浮点数，8字节小端
+	ARG_TYPE_TRUE    ArgType = 6 // 布尔值true
+	ARG_TYPE_FALSE   ArgType = 7 // 布尔值false
+)
+
+// 无模板时使用的模板id
+const NO_TEMPLATE_ID uint64 = 0
+
+// 无元数据时使用的元数据id
+const NO_METADATA_ID uint64 = 0
+
+// 单帧最大长度，超过则认为文件已损坏
+const MAX_FRAME_SIZE = 16 * 1024 * 1024
@@ -2,18 +2,84 @@ package logs
 
 import (
 	"bytes"
+	"io"
 	"os"
+	"strconv"
 	"sync"
 	"tyto/core/logs/mini"
 
 	colortext "github.com/daviddengcn/go-colortext"
 )
 
+// 颜色模式
+type ColorMode int32
+
+const (
+	COLOR_MODE_AUTO   ColorMode = 0 // 输出目标为终端且未设置NO_COLOR环境变量时开启
+	COLOR_MODE_ALWAYS ColorMode = 1 // 总是开启
+	COLOR_MODE_NEVER  ColorMode = 2 // 总是关闭
+)
+
+// 恢复默认颜色的ANSI转义序列
+const ANSI_RESET = "\x1b[0m"
+
+// 单个级别的显示样式
+type ConsoleStyle struct {
+	Color  colortext.Color // 前景色，colortext.None表示不改变颜色
+	Bright bool            // 是否高亮
+	Dim    bool            // 是否暗淡显示，旧版windows控制台下不支持
+}
+
+// 各级别的显示样式
+type ConsoleTheme [LEVEL_MAX + 1]ConsoleStyle
+
+// 默认样式，TRACE、DEBUG暗淡显示，WARN以上使用醒目的颜色
+func DefaultConsoleTheme() ConsoleTheme {
+	return ConsoleTheme{
+		LEVEL_TRACE: {Color: colortext.None, Dim: true},
+		LEVEL_DEBUG: {Color: colortext.None, Dim: true},
+		LEVEL_INFO:  {Color: colortext.None},
+		LEVEL_WARN:  {Color: colortext.Yellow, Bright: true},
+		LEVEL_ERROR: {Color: colortext.Red, Bright: true},
+		LEVEL_FATAL: {Color: colortext.Magenta, Bright: true},
+	}
+}
+
+// 控制台接收器选项
+type ConsoleOption func(*ConsoleSink)
+
+// 输出目标，默认为os.Stdout
+func WithConsoleWriter(writer io.Writer) ConsoleOption {
+	return func(sink *ConsoleSink) {
+		sink.writer = writer
+	}
+}
+
+// 颜色模式，默认为COLOR_MODE_AUTO
+func WithConsoleColorMode(mode ColorMode) ConsoleOption {
+	return func(sink *ConsoleSink) {
+		sink.colorMode = mode
+	}
+}
+
+// 各级别的显示样式
+func WithConsoleTheme(theme ConsoleTheme) ConsoleOption {
+	return func(sink *ConsoleSink) {
+		sink.theme = theme
+	}
+}
+
 // 输出到控制台的日志接收器
 // 不要用于生产环境，性能较差
 type ConsoleSink struct {
 	logger    *mini.Logger
 	formatter Formatter
+	writer    io.Writer
+	colorMode ColorMode
+	theme     ConsoleTheme
+	colored   bool
+	legacy    bool // 旧版windows控制台，不支持ANSI转义序列，通过go-colortext设置颜色
+	prefixes  [LEVEL_MAX + 1][]byte
 	mutex     sync.Mutex
 	pool      sync.Pool
 }
@@ -23,10 +89,14 @@ func NewDefaultConsoleSink(logger *mini.Logger) Sink {
 	return NewConsoleSink(logger, formatter)
 }
 
-func NewConsoleSink(logger *mini.Logger, formatter Formatter) Sink {
-	return &ConsoleSink{
+func NewConsoleSink(logger *mini.Logger, formatter Formatter, opts ...ConsoleOption) Sink {
+	sink := &ConsoleSink{
 		logger:    logger,
 		formatter: formatter,
+		writer:    os.Stdout,
+		colorMode: COLOR_MODE_AUTO,
+		theme:     DefaultConsoleTheme(),
+		colored:   false,
 		mutex:     sync.Mutex{},
 		pool: sync.Pool{
 			New: func() interface{} {
@@ -34,6 +104,63 @@ func NewConsoleSink(logger *mini.Logger, formatter Formatter) Sink {
 			},
 		},
 	}
+
+	for _, opt := range opts {
+		opt(sink)
+	}
+
+	sink.colored = sink.isColorEnabled()
+	if sink.colored {
+		// go-colortext只能设置标准输出的颜色，其他控制台不输出颜色
+		if file, ok := sink.writer.(*os.File); ok && isTerminal(file) && !enableVirtualTerminal(file) {
+			sink.legacy = true
+			sink.colored = file == os.Stdout
+		}
+	}
+
+	if sink.colored && !sink.legacy {
+		for level := LEVEL_MIN; level <= LEVEL_MAX; level++ {
+			sink.prefixes[level] = sink.theme[level].marshal()
+		}
+	}
+
+	return sink
+}
+
+// 是否输出颜色
+func (sink *ConsoleSink) isColorEnabled() bool {
+	switch sink.colorMode {
+	case COLOR_MODE_ALWAYS:
+		return true
+	case COLOR_MODE_NEVER:
+		return false
+	}
+
+	// https://no-color.org
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	file, ok := sink.writer.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return isTerminal(file)
+}
+
+// 是否为终端，重定向到文件或管道时返回false
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
 func (sink *ConsoleSink) Handle(record Record) {
@@ -42,20 +169,34 @@ func (sink *ConsoleSink) Handle(record Record) {
 
 	buff.Reset()
 
+	level := record.GetLevel()
+	painted := sink.colored && !sink.legacy && level >= LEVEL_MIN && level <= LEVEL_MAX && len(sink.prefixes[level]) != 0
+
+	// 颜色与日志一次写入，避免被其他输出打断
+	if painted {
+		buff.Write(sink.prefixes[level])
+	}
+
 	if err := sink.formatter.Format(buff, record); err != nil {
 		sink.logger.Error("failed to format log record, err:", err.Error())
 		return
 	}
 
+	if painted {
+		buff.WriteString(ANSI_RESET)
+	}
+
 	data := buff.Bytes()
 
 	sink.mutex.Lock()
 	defer sink.mutex.Unlock()
 
-	sink.BeginPaint(record)
-	defer sink.EndPaint(record)
+	if sink.legacy {
+		sink.BeginPaint(record)
+		defer sink.EndPaint(record)
+	}
 
-	_, err := os.Stdout.Write(data)
+	_, err := sink.writer.Write(data)
 	if err != nil {
 		sink.logger.Error("failed to write log record, err:", err.Error())
 		return
@@ -66,20 +207,56 @@ func (sink *ConsoleSink) Close() {
 	sink.mutex.Lock()
 	defer sink.mutex.Unlock()
 
-	os.Stdout.Sync()
+	if s, ok := sink.writer.(interface{ Sync() error }); ok {
+		s.Sync()
+	}
 }
 
+// 按级别设置标准输出的颜色，未开启颜色时什么也不做
+// 通常不需要调用，Handle会自动输出颜色
 func (sink *ConsoleSink) BeginPaint(record Record) {
-	switch record.GetLevel() {
-	case LEVEL_WARN:
-		colortext.Foreground(colortext.Yellow, true)
-	case LEVEL_ERROR:
-		colortext.Foreground(colortext.Red, true)
-	case LEVEL_FATAL:
-		colortext.Foreground(colortext.Magenta, true)
+	if !sink.colored {
+		return
+	}
+
+	level := record.GetLevel()
+	if level < LEVEL_MIN || level > LEVEL_MAX {
+		return
+	}
+
+	style := sink.theme[level]
+	if style.Color != colortext.None {
+		colortext.Foreground(style.Color, style.Bright)
 	}
 }
 
+// 恢复标准输出的颜色，与BeginPaint配对使用
 func (sink *ConsoleSink) EndPaint(record Record) {
+	if !sink.colored {
+		return
+	}
+
 	colortext.ResetColor()
 }
+
+// 转换为ANSI转义序列，无样式时返回nil
+func (style ConsoleStyle) marshal() []byte {
+	if style.Color == colortext.None && !style.Dim {
+		return nil
+	}
+
+	bs := []byte("\x1b[0")
+	if style.Dim {
+		bs = append(bs, ";2"...)
+	}
+
+	if style.Color != colortext.None {
+		bs = append(bs, ';')
+		bs = strconv.AppendInt(bs, int64(30+style.Color-colortext.Black), 10)
+		if style.Bright {
+			bs = append(bs, ";1"...)
+		}
+	}
+
+	return append(bs, 'm')
+}
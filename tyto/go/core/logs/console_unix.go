@@ -0,0 +1,10 @@
+//go:build !windows
+
+package logs
+
+import "os"
+
+// 终端都支持ANSI转义序列
+func enableVirtualTerminal(file *os.File) bool {
+	return true
+}
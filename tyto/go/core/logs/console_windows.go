@@ -0,0 +1,24 @@
+package logs
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// 为控制台开启虚拟终端处理，使其支持ANSI转义序列
+// 旧版控制台不支持时返回false
+func enableVirtualTerminal(file *os.File) bool {
+	handle := windows.Handle(file.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
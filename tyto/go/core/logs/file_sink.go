@@ -10,6 +10,9 @@ import (
 	"tyto/core/rolling"
 )
 
+// 错误日志文件只记录该级别以上的日志
+const ERROR_FILE_MIN_LEVEL = LEVEL_WARN
+
 // 文件日志接收器
 type FileSink struct {
 	logger       *mini.Logger
@@ -27,14 +30,14 @@ func NewDefaultFileSink(logger *mini.Logger, outDir string, logFileName string)
 
 func NewFileSink(logger *mini.Logger, outDir string, logFileName string, formatter Formatter) Sink {
 	// 普通日志
-	normalWriter, err := newFileSinkWriter(logger, outDir, logFileName, 2048, true)
+	normalWriter, err := newFileSinkWriter(logger, outDir, logFileName, formatter, 2048, true)
 	if err != nil {
 		logger.Error("failed to create normal file writer, err:", err.Error())
 		return nil
 	}
 
 	// 错误日志
-	errorWriter, err := newFileSinkWriter(logger, outDir, "err_"+logFileName, 256, true)
+	errorWriter, err := newFileSinkWriter(logger, outDir, "err_"+logFileName, formatter, 256, true)
 	if err != nil {
 		logger.Error("failed to create error file writer, err:", err.Error())
 		return nil
@@ -60,21 +63,26 @@ func NewFileSink(logger *mini.Logger, outDir string, logFileName string, formatt
 }
 
 // 创建
-func newFileSinkWriter(logger *mini.Logger, outDir string, logFileName string, queueSize int32, buffered bool) (*rolling.RotateWriter, error) {
+func newFileSinkWriter(logger *mini.Logger, outDir string, logFileName string, formatter Formatter, queueSize int32, buffered bool) (*rolling.RotateWriter, error) {
 	var (
-		bufferSize    int32
-		flushInterval time.Duration
+		bufferSize int32
+		// 无缓冲模式下，用于定时同步到磁盘
+		flushInterval = 5 * time.Second
 	)
 
 	if buffered {
 		// 有缓冲模式
 		bufferSize = 128 * 1024
-		flushInterval = 5 * time.Second
 
 	} else {
 		// 无缓冲模式
 		bufferSize = 0
-		flushInterval = 0
+	}
+
+	// 文件头，如BinaryFormatter的模板定义
+	var header rolling.FileHeader
+	if f, ok := formatter.(FileHeaderFormatter); ok {
+		header = f.FileHeader
 	}
 
 	return rolling.NewRotateWriter(
@@ -87,6 +95,7 @@ func newFileSinkWriter(logger *mini.Logger, outDir string, logFileName string, q
 		rolling.WithWriterQueueSize(queueSize),
 		rolling.WithBufferSize(bufferSize),
 		rolling.WithFlushInterval(flushInterval),
+		rolling.WithFileHeader(header),
 		rolling.WithLogger(logger),
 	)
 }
@@ -116,7 +125,7 @@ func (sink *FileSink) Handle(record Record) {
 	}
 
 	// 错入日志
-	if record.GetLevel() >= LEVEL_WARN {
+	if record.GetLevel() >= ERROR_FILE_MIN_LEVEL {
 		if _, err := sink.errorWriter.WriteBuffer(buff); err != nil {
 			sink.logger.Error("failed to write error log record, err:", err.Error())
 		}
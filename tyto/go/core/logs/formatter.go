@@ -1,9 +1,19 @@
 package logs
 
-import "bytes"
+import (
+	"bytes"
+	"time"
+)
 
 // 单个日志项格式化器
 type Formatter interface {
 	// 格式化日志
 	Format(buff *bytes.Buffer, record Record) error
 }
+
+// 需要在每个新文件开头写入文件头的格式化器
+type FileHeaderFormatter interface {
+	Formatter
+	// 生成新文件的文件头，在写入协程中调用
+	FileHeader(fileName string, t time.Time) []byte
+}
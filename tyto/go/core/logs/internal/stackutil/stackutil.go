@@ -38,3 +38,22 @@ func formatFrame(buff *bytes.Buffer, pc uintptr) {
 	buff.WriteString(strconv.Itoa(line))
 	buff.WriteByte('\n')
 }
+
+// 获取当前goroutine的id，性能较差，仅用于调试
+func GoroutineId() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+
+	// 格式为"goroutine 123 [running]:..."
+	bs := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(bs, ' '); i > 0 {
+		bs = bs[:i]
+	}
+
+	id, err := strconv.ParseInt(string(bs), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
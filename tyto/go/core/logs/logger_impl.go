@@ -3,12 +3,15 @@ package logs
 import (
 	"sync"
 	"sync/atomic"
+	"tyto/core/logs/internal/stackutil"
 )
 
 type LoggerImpl struct {
-	pool  sync.Pool
-	level atomic.Int32
-	sinks []Sink
+	pool        sync.Pool
+	level       atomic.Int32
+	sinks       []Sink
+	metadata    atomic.Pointer[Metadata]
+	goroutineId atomic.Bool
 }
 
 func NewLoggerImpl(sinks ...Sink) *LoggerImpl {
@@ -18,8 +21,10 @@ func NewLoggerImpl(sinks ...Sink) *LoggerImpl {
 				return NewTextRecord()
 			},
 		},
-		level: atomic.Int32{},
-		sinks: sinks,
+		level:       atomic.Int32{},
+		sinks:       sinks,
+		metadata:    atomic.Pointer[Metadata]{},
+		goroutineId: atomic.Bool{},
 	}
 
 	logger.level.Store(int32(LEVEL_DEBUG))
@@ -100,6 +105,25 @@ func (logger *LoggerImpl) SetLevel(level int32) {
 	logger.level.Store(level)
 }
 
+// 设置进程元数据，所有格式化器都会输出，nil表示不输出
+func (logger *LoggerImpl) SetMetadata(metadata *Metadata) {
+	logger.metadata.Store(metadata)
+}
+
+func (logger *LoggerImpl) GetMetadata() *Metadata {
+	return logger.metadata.Load()
+}
+
+// 是否在每条日志中记录goroutine id
+// 获取goroutine id的开销较大，通常只在调试竞态问题时开启
+func (logger *LoggerImpl) SetGoroutineIdEnabled(enabled bool) {
+	logger.goroutineId.Store(enabled)
+}
+
+func (logger *LoggerImpl) IsGoroutineIdEnabled() bool {
+	return logger.goroutineId.Load()
+}
+
 func (logger *LoggerImpl) Close() {
 	for _, sink := range logger.sinks {
 		if sink == nil {
@@ -115,6 +139,10 @@ func (logger *LoggerImpl) log(level Level, reportCallerType ReportCallerType, v
 	defer logger.pool.Put(record)
 
 	record.Reset(level, reportCallerType, v)
+	record.Metadata = logger.metadata.Load()
+	if logger.goroutineId.Load() {
+		record.GoroutineId = stackutil.GoroutineId()
+	}
 
 	for _, sink := range logger.sinks {
 		if sink == nil {
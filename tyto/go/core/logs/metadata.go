@@ -0,0 +1,83 @@
+package logs
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// 进程元数据，会输出到每条日志记录中
+// 用于多个实例输出到同一个日志收集器时，区分日志来源
+type Metadata struct {
+	service  string // 服务名
+	instance string // 实例编号，通常为daemonutil.Start的code参数
+	host     string // 主机名
+	pid      int32  // 进程id
+	text     []byte // 格式化后的文本，只生成一次
+}
+
+// 创建元数据，主机名和进程id自动获取
+func NewMetadata(service string, instance string) *Metadata {
+	host, err := os.Hostname()
+	if err != nil {
+		host = ""
+	}
+
+	return NewMetadataWith(service, instance, host, int32(os.Getpid()))
+}
+
+// 使用指定的值创建元数据，空字符串或pid<=0的字段不会输出
+func NewMetadataWith(service string, instance string, host string, pid int32) *Metadata {
+	m := &Metadata{
+		service:  service,
+		instance: instance,
+		host:     host,
+		pid:      pid,
+	}
+
+	buff := bytes.Buffer{}
+	m.appendField(&buff, "service", service)
+	m.appendField(&buff, "instance", instance)
+	m.appendField(&buff, "host", host)
+	if pid > 0 {
+		m.appendField(&buff, "pid", strconv.Itoa(int(pid)))
+	}
+	m.text = buff.Bytes()
+
+	return m
+}
+
+func (m *Metadata) appendField(buff *bytes.Buffer, key string, value string) {
+	if value == "" {
+		return
+	}
+
+	if buff.Len() > 0 {
+		buff.WriteByte(' ')
+	}
+
+	buff.WriteString(key)
+	buff.WriteByte('=')
+	buff.WriteString(value)
+}
+
+func (m *Metadata) Service() string {
+	return m.service
+}
+
+func (m *Metadata) Instance() string {
+	return m.instance
+}
+
+func (m *Metadata) Host() string {
+	return m.host
+}
+
+func (m *Metadata) Pid() int32 {
+	return m.pid
+}
+
+// 格式化为"service=game instance=1001 host=s1 pid=123"，返回值不要修改
+func (m *Metadata) Marshal() []byte {
+	return m.text
+}
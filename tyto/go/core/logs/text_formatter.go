@@ -3,6 +3,7 @@ package logs
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"tyto/core/logs/internal/stackutil"
 	"tyto/core/logs/internal/timeutil"
 )
@@ -45,6 +46,20 @@ func (f *TextFormatter) formatTextRecord(buff *bytes.Buffer, record Record) erro
 	buff.Write(r.Level.Marshal())
 	buff.WriteByte(']')
 
+	// 进程元数据
+	if r.Metadata != nil {
+		buff.WriteString(" [")
+		buff.Write(r.Metadata.Marshal())
+		buff.WriteByte(']')
+	}
+
+	// goroutine id
+	if r.GoroutineId != 0 {
+		buff.WriteString(" [goid=")
+		buff.WriteString(strconv.FormatInt(r.GoroutineId, 10))
+		buff.WriteByte(']')
+	}
+
 	// 日志内容
 	buff.WriteByte(' ')
 	fmt.Fprintln(buff, r.Args...)
@@ -11,6 +11,8 @@ type TextRecord struct {
 	ReportCallerType ReportCallerType
 	Time             time.Time
 	Args             []interface{}
+	Metadata         *Metadata // 进程元数据，可能为nil
+	GoroutineId      int64     // 产生日志的goroutine id，0表示未记录
 }
 
 func NewTextRecord() *TextRecord {
@@ -25,6 +27,8 @@ func (r *TextRecord) Reset(level Level, reportCallerType ReportCallerType, args
 	r.ReportCallerType = reportCallerType
 	r.Time = time.Now().Local()
 	r.Args = args
+	r.Metadata = nil
+	r.GoroutineId = 0
 }
 
 func (r *TextRecord) GetRecordType() RecordType {
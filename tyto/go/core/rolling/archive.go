@@ -0,0 +1,146 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 是否将旧文件移动到归档目录
+func (w *RotateWriter) isArchiveEnabled() bool {
+	return w.options.ArchiveDir != ""
+}
+
+// 列出所有由NamePattern生成的文件，包括归档目录中的文件
+func (w *RotateWriter) listFiles() ([]string, error) {
+	fileList, err := internal.ListFiles(w.options.OutDir, w.globPattern)
+	if err != nil || !w.isArchiveEnabled() {
+		return fileList, err
+	}
+
+	archiveGlob := filepath.Join(internal.ToGlobPattern(w.options.ArchiveDir), w.globPattern)
+	archived, err := internal.ListFiles(w.options.OutDir, archiveGlob)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(fileList, archived...), nil
+}
+
+// 将已关闭的文件移动到fileTime对应的归档目录，返回移动后的路径，失败时返回原路径
+func (w *RotateWriter) archive(path string, fileTime time.Time) string {
+	dir := filepath.Join(w.options.OutDir, internal.GenerateFileName(w.options.ArchiveDir, fileTime))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		w.Logger().Error("create archive dir", dir, "failed:", err.Error())
+		return path
+	}
+
+	// 归档目录中已存在同名文件时，追加序号，避免覆盖
+	name := filepath.Base(path)
+	target := filepath.Join(dir, name)
+	for index := int32(1); w.isPathExist(target); index++ {
+		target = filepath.Join(dir, internal.AppendIndex(name, index))
+	}
+
+	if err := os.Rename(path, target); err != nil {
+		w.Logger().Error("archive", path, "->", target, "failed:", err.Error())
+		return path
+	}
+
+	// 完成标记随文件一起移动
+	if err := os.Rename(path+internal.DONE_SUFFIX, target+internal.DONE_SUFFIX); err != nil && !os.IsNotExist(err) {
+		w.Logger().Error("archive", path+internal.DONE_SUFFIX, "failed:", err.Error())
+	}
+
+	return target
+}
+
+// 启动时，将输出目录中的旧文件移动到归档目录，只保留当前文件
+// 上次运行时最后写入的文件没有经过旋转，在recoverState中处理
+// 归档目录的时间从文件名中解析，无法解析时使用文件的修改时间
+func (w *RotateWriter) archiveStaleFiles() {
+	if !w.isArchiveEnabled() {
+		return
+	}
+
+	fileList, err := internal.ListFiles(w.options.OutDir, w.globPattern)
+	if err != nil {
+		w.Logger().Error("list file failed:", err.Error())
+		return
+	}
+
+	currentFile := ""
+	if w.fileName != "" {
+		currentFile = filepath.Join(w.options.OutDir, w.fileName)
+	}
+	linkPath := ""
+	if len(w.options.LinkName) != 0 {
+		linkPath = filepath.Join(w.options.OutDir, w.options.LinkName)
+	}
+
+	for _, file := range fileList {
+		if file == currentFile || file == linkPath {
+			continue
+		}
+
+		fileInfo, err := os.Lstat(file)
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+
+		fileTime, ok := internal.ParseFileTime(w.options.NamePattern, filepath.Base(file), w.options.Location)
+		if !ok {
+			fileTime = w.getBaseTime(fileInfo.ModTime(), w.options.RotationInterval)
+		}
+
+		w.archive(file, fileTime)
+	}
+}
+
+// 归档目录中所有文件的文件名，不含压缩后缀，未开启归档时返回nil
+func (w *RotateWriter) listArchivedNames() map[string]struct{} {
+	if !w.isArchiveEnabled() {
+		return nil
+	}
+
+	archiveGlob := filepath.Join(internal.ToGlobPattern(w.options.ArchiveDir), w.globPattern)
+	archived, err := internal.ListFiles(w.options.OutDir, archiveGlob)
+	if err != nil {
+		w.Logger().Error("list archived file failed:", err.Error())
+		return nil
+	}
+
+	names := make(map[string]struct{}, len(archived))
+	for _, file := range archived {
+		names[internal.TrimCompressionSuffix(filepath.Base(file))] = struct{}{}
+	}
+
+	return names
+}
+
+// 文件或其压缩后的文件是否存在
+func (w *RotateWriter) isPathExist(path string) bool {
+	if _, err := os.Lstat(path); err == nil {
+		return true
+	}
+
+	return w.isCompressed(path)
+}
+
+// 删除文件后，逐级删除归档目录中的空目录，不会删除OutDir
+func (w *RotateWriter) removeEmptyDirs(dir string) {
+	for dir != w.options.OutDir && filepath.Dir(dir) != dir {
+		rel, err := filepath.Rel(w.options.OutDir, dir)
+		if err != nil || !filepath.IsLocal(rel) {
+			return
+		}
+
+		// 目录不为空时删除失败
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,135 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 列出dir下的所有文件，返回相对于dir的路径
+func listTree(t testing.TB, dir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+func checkTree(t testing.TB, dir string, want []string) {
+	t.Helper()
+
+	got := listTree(t, dir)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+}
+
+func TestArchiveStaleFilesOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	opts := []Option{
+		WithArchiveDir("archive/%F"),
+		WithMaxSize(20),
+	}
+
+	// 第一次运行：写满3个文件，最后一个文件在关闭时没有经过旋转
+	w := newTestWriter(t, dir, clock, opts...)
+	for i := 0; i < 6; i++ {
+		mustWrite(t, w, "123456789\n")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, dir, []string{
+		"app.log.2026-01-01.2",
+		"archive/2026-01-01/app.log.2026-01-01",
+		"archive/2026-01-01/app.log.2026-01-01.1",
+	})
+
+	// 第二次运行：旧文件已写满，启动时归档，新文件不能复用已归档的序号
+	w = newTestWriter(t, dir, clock, opts...)
+	mustWrite(t, w, "123456789\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, dir, []string{
+		"app.log.2026-01-01.3",
+		"archive/2026-01-01/app.log.2026-01-01",
+		"archive/2026-01-01/app.log.2026-01-01.1",
+		"archive/2026-01-01/app.log.2026-01-01.2",
+	})
+}
+
+func TestArchiveSequenceNaming(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	opts := []Option{
+		WithArchiveDir("archive/%F"),
+		WithFileNaming(FILE_NAMING_SEQUENCE),
+	}
+
+	// 每次运行都创建新文件，上次运行的文件在启动时归档
+	for run := 0; run < 3; run++ {
+		w := newTestWriter(t, dir, clock, opts...)
+		mustWrite(t, w, "data\n")
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkTree(t, dir, []string{
+		"app.log.2026-01-01.2",
+		"archive/2026-01-01/app.log.2026-01-01",
+		"archive/2026-01-01/app.log.2026-01-01.1",
+	})
+
+	// 所有序号都可以被解析，不会出现归档冲突产生的文件名
+	for _, file := range listTree(t, filepath.Join(dir, "archive")) {
+		name := filepath.Base(file)
+		if name == "app.log.2026-01-01" {
+			continue
+		}
+		if _, ok := internal.ParseIndex("app.log.2026-01-01", name); !ok || strings.Count(name, ".") != 3 {
+			t.Errorf("unexpected archived name %q", name)
+		}
+	}
+}
+
+func TestArchiveMovesDoneMarker(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+
+	old := filepath.Join(dir, "app.log.2025-12-31")
+	for _, path := range []string{old, old + DONE_SUFFIX} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modTime := testStartTime.Add(-25 * time.Hour)
+	if err := os.Chtimes(old, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	newTestWriter(t, dir, clock, WithArchiveDir("archive/%F"))
+
+	checkTree(t, dir, []string{
+		"archive/2025-12-31/app.log.2025-12-31",
+		"archive/2025-12-31/app.log.2025-12-31" + DONE_SUFFIX,
+	})
+}
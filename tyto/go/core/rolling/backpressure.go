@@ -0,0 +1,67 @@
+package rolling
+
+import (
+	"errors"
+	"tyto/core/rolling/internal"
+)
+
+// 写入队列已满时的处理策略
+type QueuePolicy = internal.QueuePolicy
+
+const (
+	QUEUE_POLICY_BLOCK          = internal.QUEUE_POLICY_BLOCK          // 阻塞调用者，直到队列有空位
+	QUEUE_POLICY_DROP           = internal.QUEUE_POLICY_DROP           // 丢弃新写入的数据
+	QUEUE_POLICY_DROP_AND_COUNT = internal.QUEUE_POLICY_DROP_AND_COUNT // 丢弃新写入的数据，并统计丢弃次数
+)
+
+// 写入队列已满，数据被丢弃
+var ErrDropped = errors.New("rolling: write queue is full, data dropped")
+
+// 为即将写入的数据占用队列空位，返回false表示数据需要丢弃
+func (w *RotateWriter) acquireSlot() bool {
+	if w.slots == nil {
+		return true
+	}
+
+	switch w.options.QueuePolicy {
+	case QUEUE_POLICY_DROP:
+		select {
+		case w.slots <- struct{}{}:
+			return true
+		default:
+			return false
+		}
+
+	case QUEUE_POLICY_DROP_AND_COUNT:
+		select {
+		case w.slots <- struct{}{}:
+			return true
+		default:
+			w.dropped.Add(1)
+			return false
+		}
+
+	default:
+		w.slots <- struct{}{}
+		return true
+	}
+}
+
+// 数据处理完毕，释放队列空位
+func (w *RotateWriter) releaseSlot() {
+	if w.slots == nil {
+		return
+	}
+
+	<-w.slots
+}
+
+// 写入队列中等待处理的事件数量，可在任意协程调用
+func (w *RotateWriter) QueueLen() int32 {
+	return w.queue.Len()
+}
+
+// 因队列已满而丢弃的写入次数，只在QUEUE_POLICY_DROP_AND_COUNT策略下统计
+func (w *RotateWriter) DroppedCount() int64 {
+	return w.dropped.Load()
+}
@@ -0,0 +1,81 @@
+package rolling
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueueLenConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock)
+
+	const (
+		writers = 4
+		count   = 1000
+	)
+
+	done := make(chan struct{})
+	var monitor sync.WaitGroup
+	monitor.Add(1)
+	go func() {
+		defer monitor.Done()
+
+		// 与写入协程并发读取队列长度
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if n := w.QueueLen(); n < 0 {
+					t.Errorf("QueueLen = %d", n)
+				}
+				_ = w.Stats()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				_, _ = w.WriteString("x\n")
+			}
+		}()
+	}
+	wg.Wait()
+
+	mustSync(t, w)
+	close(done)
+	monitor.Wait()
+
+	if n := w.QueueLen(); n != 0 {
+		t.Errorf("QueueLen = %d after Sync, want 0", n)
+	}
+	if n := w.Stats().RecordsWritten; n != writers*count {
+		t.Errorf("RecordsWritten = %d, want %d", n, writers*count)
+	}
+}
+
+func TestQueuePolicyDropAndCount(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock, WithMaxQueueSize(1, QUEUE_POLICY_DROP_AND_COUNT))
+
+	// 占住唯一的空位，之后的写入都会被丢弃
+	w.slots <- struct{}{}
+
+	if _, err := w.WriteString("dropped\n"); err != ErrDropped {
+		t.Fatalf("write = %v, want ErrDropped", err)
+	}
+	if n := w.DroppedCount(); n != 1 {
+		t.Errorf("DroppedCount = %d, want 1", n)
+	}
+
+	<-w.slots
+	mustWrite(t, w, "kept\n")
+	mustSync(t, w)
+}
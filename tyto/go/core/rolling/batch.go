@@ -0,0 +1,160 @@
+package rolling
+
+import (
+	"net"
+	"tyto/core/rolling/internal"
+)
+
+// 一次批量写入的最大记录数
+const MAX_BATCH_SIZE = 256
+
+// 从first开始，取出队列中连续的写入事件，一次性写入
+// 遇到其他类型的事件时停止，并返回该事件，ok为false表示没有需要继续处理的事件
+func (w *RotateWriter) handleBufferEvents(first internal.Event) (next internal.Event, ok bool) {
+	buffs := append(w.batch[:0], first.Buffer)
+
+	for len(buffs) < MAX_BATCH_SIZE {
+		e, err := w.queue.TryPop()
+		if err != nil {
+			break
+		}
+
+		if e.Type != internal.EVENT_TYPE_BUFFER {
+			next, ok = e, true
+			break
+		}
+
+		buffs = append(buffs, e.Buffer)
+	}
+
+	err := w.handleBuffers(buffs)
+	for i := range buffs {
+		w.releaseSlot()
+		buffs[i] = nil
+	}
+	w.batch = buffs[:0]
+
+	if err != nil {
+		w.setLastError(err)
+	}
+
+	return next, ok
+}
+
+// 批量写入，降级模式下逐条处理
+func (w *RotateWriter) handleBuffers(buffs []*BufferType) error {
+	if len(buffs) == 1 || w.degrade.degraded {
+		var lastErr error
+		for _, buff := range buffs {
+			if err := w.handleBuffer(buff); err != nil {
+				lastErr = err
+			}
+		}
+
+		return lastErr
+	}
+
+	// 释放资源
+	defer func() {
+		for _, buff := range buffs {
+			buff.DecRef()
+		}
+	}()
+
+	records := w.records[:0]
+	for _, buff := range buffs {
+		records = append(records, buff.Object().Bytes())
+	}
+
+	written, err := w.writeBatch(records)
+	clear(records)
+	w.records = records[:0]
+
+	if err != nil {
+		// 未写入的数据交由降级模式处理
+		w.enterDegraded(err)
+		for _, buff := range buffs[written:] {
+			_ = w.writeDegraded(buff)
+		}
+	}
+
+	return err
+}
+
+// 写入多条记录，文件需要旋转时会自动旋转，不需要旋转的连续记录使用一次向量化io写入
+// 返回已写入文件的记录数，包括只写入了一部分的记录，这些记录不能再重新写入
+func (w *RotateWriter) writeBatch(records [][]byte) (int, error) {
+	w.checkFile(w.now(), false)
+
+	written := 0
+	for written < len(records) {
+		if err := w.rotate(len(records[written])); err != nil {
+			// 通常是权限问题、磁盘空间问题导致文件创建失败
+			return written, err
+		}
+
+		// 找出当前文件还能容纳的记录
+		end := written + 1
+		size := int64(len(records[written]))
+		for end < len(records) && w.isFit(size, len(records[end])) {
+			size += int64(len(records[end]))
+			end++
+		}
+
+		// WriteBuffers会修改bufs，使用副本
+		bufs := append(w.iovecs[:0], records[written:end]...)
+		n, err := w.writer.WriteBuffers(net.Buffers(bufs))
+		clear(bufs)
+		w.iovecs = bufs[:0]
+
+		w.fileSize += n
+		w.stats.fileSize.Store(w.fileSize)
+		w.stats.bytesWritten.Add(n)
+
+		if err != nil {
+			// 已写入的记录重新写入会导致重复，只有未写入的记录交由调用方处理
+			written += w.skipWritten(records[written:end], n)
+
+			// 出错后，缓冲区中的数据无法再写入，关闭后重新打开
+			_ = w.closeWriter()
+			return written, err
+		}
+
+		w.stats.recordsWritten.Add(int64(end - written))
+		written = end
+
+		if err := w.syncAfterWrite(int(n)); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// 写入出错时，根据已写入的字节数n，返回已写入的记录数
+// 只写入了一部分的记录无法撤回，丢弃剩余部分
+func (w *RotateWriter) skipWritten(records [][]byte, n int64) int {
+	written := 0
+	for written < len(records) && n >= int64(len(records[written])) {
+		n -= int64(len(records[written]))
+		written++
+	}
+	w.stats.recordsWritten.Add(int64(written))
+
+	if written < len(records) && n > 0 {
+		w.Logger().Error("record partially written to", w.fileName, "dropped:", int64(len(records[written]))-n, "bytes")
+		written++
+	}
+
+	return written
+}
+
+// 已有pending字节等待写入时，再写入size字节是否不超过大小限制
+// 与isSizeExceeded一致，空文件总是可以写入
+func (w *RotateWriter) isFit(pending int64, size int) bool {
+	if w.options.MaxSize <= 0 || w.fileSize+pending <= w.headerSize {
+		return true
+	}
+
+	return w.fileSize+pending+int64(size) <= w.options.MaxSize
+}
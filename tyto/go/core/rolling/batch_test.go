@@ -0,0 +1,146 @@
+package rolling
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 模拟高负载时，队列中积压的写入事件数量
+const benchBatchSize = 64
+
+// 写入limit字节后返回错误，模拟写入过程中磁盘写满
+type partialWriter struct {
+	internal.Writer
+	limit int
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (p *partialWriter) WriteBuffers(bufs net.Buffers) (int64, error) {
+	data := bytes.Join(bufs, nil)
+	if len(data) <= p.limit {
+		n, err := p.Writer.Write(data)
+		p.limit -= n
+		return int64(n), err
+	}
+
+	n, _ := p.Writer.Write(data[:p.limit])
+	p.limit -= n
+	return int64(n), errDiskFull
+}
+
+// 部分写入失败时，已写入文件的记录不会再写入备用文件，只写入了一部分的记录被丢弃
+func TestRotateWriterPartialWrite(t *testing.T) {
+	cases := []struct {
+		name     string
+		records  []string
+		limit    int
+		file     string
+		fallback string
+	}{
+		{"batched", []string{"r0\n", "r1\n", "r2\n", "r3\n"}, 4, "head\nr0\nr", "r2\nr3\n"},
+		{"single", []string{"r0\n"}, 1, "head\nr", ""},
+		{"unwritten", []string{"r0\n", "r1\n"}, 0, "head\n", "r0\nr1\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fallbackDir := t.TempDir()
+			clock := NewFakeClock(testStartTime.Add(-time.Hour))
+			w := newTestWriter(t, dir, clock, WithFallbackDir(fallbackDir))
+
+			mustWrite(t, w, "head\n")
+			mustSync(t, w)
+
+			// 写入协程阻塞在Pop上，直接调用处理函数
+			w.writer = &partialWriter{Writer: w.writer, limit: c.limit}
+
+			buffs := make([]*BufferType, 0, len(c.records))
+			for _, record := range c.records {
+				buff := w.pool.Load().Get().(*BufferType)
+				buff.IncRef()
+				buff.Object().Reset()
+				buff.Object().WriteString(record)
+				buffs = append(buffs, buff)
+			}
+
+			if err := w.handleBuffers(buffs); err != errDiskFull {
+				t.Fatalf("handleBuffers = %v, want errDiskFull", err)
+			}
+
+			name := "app.log.2026-01-01"
+			if got := readFile(t, filepath.Join(dir, name)); got != c.file {
+				t.Errorf("file = %q, want %q", got, c.file)
+			}
+
+			fallback, err := os.ReadFile(filepath.Join(fallbackDir, name))
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal("read fallback failed:", err)
+			}
+			if string(fallback) != c.fallback {
+				t.Errorf("fallback = %q, want %q", fallback, c.fallback)
+			}
+		})
+	}
+}
+
+// 对比批量的向量化写入与逐条写入
+// 直接调用写入协程的处理函数，此时队列为空，写入协程阻塞在Pop上，不会并发访问
+func benchmarkRotateWriter(b *testing.B, bufferSize int32, batched bool) {
+	dir := b.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(b, dir, clock,
+		WithBufferSize(bufferSize),
+		WithFlushInterval(time.Hour),
+		WithSyncPolicy(SYNC_POLICY_NONE, 0),
+	)
+	w.tryInitPool()
+
+	data := []byte(strings.Repeat("x", 127) + "\n")
+	buffs := make([]*BufferType, 0, benchBatchSize)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i += benchBatchSize {
+		buffs = buffs[:0]
+		for j := 0; j < benchBatchSize && i+j < b.N; j++ {
+			buff := w.pool.Load().Get().(*BufferType)
+			buff.IncRef()
+			buff.Object().Reset()
+			buff.Object().Write(data)
+			buffs = append(buffs, buff)
+		}
+
+		if batched {
+			if err := w.handleBuffers(buffs); err != nil {
+				b.Fatal(err)
+			}
+			continue
+		}
+
+		for _, buff := range buffs {
+			if err := w.handleBuffer(buff); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRotateWriterBuffered(b *testing.B) {
+	b.Run("batched", func(b *testing.B) { benchmarkRotateWriter(b, 64*1024, true) })
+	b.Run("single", func(b *testing.B) { benchmarkRotateWriter(b, 64*1024, false) })
+}
+
+func BenchmarkRotateWriterUnbuffered(b *testing.B) {
+	b.Run("batched", func(b *testing.B) { benchmarkRotateWriter(b, 0, true) })
+	b.Run("single", func(b *testing.B) { benchmarkRotateWriter(b, 0, false) })
+}
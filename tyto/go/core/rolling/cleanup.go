@@ -0,0 +1,156 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 待清理的文件信息
+type cleanupFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// 是否需要清理旧文件
+func (w *RotateWriter) isCleanupEnabled() bool {
+	return w.options.MaxAge > 0 || w.options.MaxBackups > 0 || w.options.MaxTotalSize > 0
+}
+
+// 清理过时的文件
+// 文件数量和总大小每次打开文件时都检查，修改时间只在到达清理时间时检查
+func (w *RotateWriter) tryCleanup(now time.Time) {
+	if !w.isCleanupEnabled() {
+		return
+	}
+
+	checkAge := w.options.MaxAge > 0 && !now.Before(w.nextCleanupTime)
+	if !checkAge && w.options.MaxBackups <= 0 && w.options.MaxTotalSize <= 0 {
+		return
+	}
+
+	// 正在清理时跳过，修改时间留到下次检查
+	if w.cleanup(checkAge) && checkAge {
+		baseTime := w.getBaseTime(now, w.options.CleanupInterval)
+		w.nextCleanupTime = w.getNextTime(baseTime, w.options.CleanupInterval)
+	}
+}
+
+// 清理过时的文件，checkAge为false时不检查修改时间
+// 已有清理协程在运行时返回false
+func (w *RotateWriter) cleanup(checkAge bool) bool {
+	// 文件清理过于耗时，清理间隔过短，可能会导致同时启动多个清理协程，因而进行限制
+	if w.cleaning.Load() {
+		return false
+	}
+	if !w.cleaning.CompareAndSwap(false, true) {
+		return false
+	}
+
+	fileList, err := w.listFiles()
+	if err != nil {
+		w.cleaning.Store(false)
+		w.Logger().Error("list file failed:", err.Error())
+		return true
+	}
+
+	currentFile := filepath.Join(w.options.OutDir, w.fileName)
+	currentSize := w.fileSize
+	linkPath := ""
+	if len(w.options.LinkName) != 0 {
+		linkPath = filepath.Join(w.options.OutDir, w.options.LinkName)
+	}
+
+	w.stats.cleanupCount.Add(1)
+
+	go func() {
+		defer w.cleaning.Store(false)
+
+		for _, file := range w.selectExpiredFiles(fileList, currentFile, linkPath, currentSize, checkAge) {
+			err := os.Remove(file)
+			if err != nil {
+				w.Logger().Error("remove", file, "failed:", err.Error())
+				continue
+			}
+
+			// 同时删除完成标记
+			_ = os.Remove(file + internal.DONE_SUFFIX)
+
+			if w.isArchiveEnabled() {
+				w.removeEmptyDirs(filepath.Dir(file))
+			}
+
+			w.stats.removedCount.Add(1)
+			w.notifyCleanup(file)
+		}
+	}()
+
+	return true
+}
+
+// 选出需要删除的文件，当前文件和符号链接永远不会被选中
+func (w *RotateWriter) selectExpiredFiles(fileList []string, currentFile string, linkPath string, currentSize int64, checkAge bool) []string {
+	files := make([]cleanupFile, 0, len(fileList))
+
+	for _, file := range fileList {
+		// 跳过当前文件
+		if file == currentFile || file == linkPath {
+			continue
+		}
+
+		fileInfo, err := os.Lstat(file)
+		if err != nil {
+			continue
+		}
+
+		if !fileInfo.Mode().IsRegular() {
+			// 不是正常的文件
+			continue
+		}
+
+		files = append(files, cleanupFile{
+			path:    file,
+			modTime: fileInfo.ModTime(),
+			size:    fileInfo.Size(),
+		})
+	}
+
+	// 从新到旧排序
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path > files[j].path
+		}
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var (
+		expiredTime  = w.now().Add(-w.options.MaxAge)
+		totalSize    = currentSize
+		exceeded     = false
+		expiredFiles = make([]string, 0, len(files))
+	)
+
+	for i, file := range files {
+		// 超过总大小限制后，更旧的文件都需要删除
+		if w.options.MaxTotalSize > 0 && !exceeded {
+			totalSize += file.size
+			exceeded = totalSize > w.options.MaxTotalSize
+		}
+
+		switch {
+		case checkAge && w.options.MaxAge > 0 && !file.modTime.After(expiredTime):
+		case w.options.MaxBackups > 0 && int32(i) >= w.options.MaxBackups:
+		case exceeded:
+		default:
+			// 保留
+			continue
+		}
+
+		expiredFiles = append(expiredFiles, file.path)
+	}
+
+	return expiredFiles
+}
@@ -0,0 +1,119 @@
+package rolling
+
+import (
+	"sync"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 时钟，用于获取当前时间、创建定时器
+type Clock = internal.Clock
+
+// 定时器，与time.Ticker的行为一致
+type Ticker = internal.Ticker
+
+// 手动推进的时钟，用于测试旋转、清理等与时间相关的逻辑
+type FakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+type fakeTicker struct {
+	clock    *FakeClock
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+	stopped  bool
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		mutex:   sync.Mutex{},
+		now:     now,
+		tickers: nil,
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ticker := &fakeTicker{
+		clock:    c,
+		c:        make(chan time.Time, 1),
+		interval: d,
+		next:     c.now.Add(d),
+		stopped:  false,
+	}
+	c.tickers = append(c.tickers, ticker)
+
+	return ticker
+}
+
+// 将时钟向前推进d，并触发到期的定时器
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.setLocked(c.now.Add(d))
+}
+
+// 将时钟设置为t，t早于当前时间时，定时器不会触发
+func (c *FakeClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.setLocked(t)
+}
+
+func (c *FakeClock) setLocked(t time.Time) {
+	c.now = t
+
+	for _, ticker := range c.tickers {
+		if ticker.stopped || ticker.next.After(t) {
+			continue
+		}
+
+		// 与time.Ticker一致，接收方来不及处理时丢弃
+		select {
+		case ticker.c <- t:
+		default:
+		}
+
+		// 跳过多个周期时只触发一次
+		for !ticker.next.After(t) {
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	t.stopped = true
+
+	tickers := t.clock.tickers[:0]
+	for _, ticker := range t.clock.tickers {
+		if ticker != t {
+			tickers = append(tickers, ticker)
+		}
+	}
+	t.clock.tickers = tickers
+}
@@ -0,0 +1,112 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"tyto/core/rolling/internal"
+)
+
+// 压缩方式
+type Compression = internal.Compression
+
+const (
+	COMPRESSION_NONE = internal.COMPRESSION_NONE // 不压缩
+	COMPRESSION_GZIP = internal.COMPRESSION_GZIP // gzip
+)
+
+// 压缩任务
+type compressTask struct {
+	path    string // 需要压缩的文件
+	newPath string // 旋转后的新文件，用于回调
+}
+
+// 将旋转后的文件加入压缩队列，由后台协程逐个压缩
+func (w *RotateWriter) compress(path string, newPath string) {
+	w.compressMutex.Lock()
+	defer w.compressMutex.Unlock()
+
+	w.compressQueue = append(w.compressQueue, compressTask{path: path, newPath: newPath})
+
+	// 同一时间只有一个压缩协程
+	if w.compressing {
+		return
+	}
+
+	w.compressing = true
+	go w.runCompress()
+}
+
+func (w *RotateWriter) runCompress() {
+	for {
+		w.compressMutex.Lock()
+		if len(w.compressQueue) == 0 {
+			w.compressing = false
+			w.compressMutex.Unlock()
+			return
+		}
+
+		task := w.compressQueue[0]
+		w.compressQueue = w.compressQueue[1:]
+		w.compressMutex.Unlock()
+
+		// 同一文件可能在启动时重复加入队列，已压缩或已删除时跳过
+		if _, err := os.Lstat(task.path); os.IsNotExist(err) {
+			continue
+		}
+
+		closedPath := task.path
+
+		switch w.options.Compression {
+		case COMPRESSION_GZIP:
+			if err := internal.GzipFile(task.path, w.options.CompressionLevel); err != nil {
+				w.Logger().Error("compress", task.path, "failed:", err.Error())
+			} else {
+				closedPath = task.path + w.options.Compression.Suffix()
+			}
+		}
+
+		w.notifyRotate(closedPath, task.newPath)
+	}
+}
+
+// 启动时，压缩上次运行时没有压缩的旧文件，包括归档目录中的文件
+// 通常是进程退出时仍在压缩队列中的文件，压缩完成后回调，newPath为当前文件，可能为空
+func (w *RotateWriter) compressStaleFiles() {
+	if w.options.Compression == COMPRESSION_NONE {
+		return
+	}
+
+	fileList, err := w.listFiles()
+	if err != nil {
+		w.Logger().Error("list file failed:", err.Error())
+		return
+	}
+
+	currentFile := ""
+	if w.fileName != "" {
+		currentFile = filepath.Join(w.options.OutDir, w.fileName)
+	}
+	linkPath := ""
+	if len(w.options.LinkName) != 0 {
+		linkPath = filepath.Join(w.options.OutDir, w.options.LinkName)
+	}
+
+	for _, file := range fileList {
+		// 当前文件和上次运行时最后写入的文件由旋转处理
+		if file == currentFile || file == linkPath || file == w.closedFile {
+			continue
+		}
+
+		// 已压缩，或者压缩后的文件已存在
+		if internal.TrimCompressionSuffix(file) != file || w.isCompressed(file) {
+			continue
+		}
+
+		fileInfo, err := os.Lstat(file)
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+
+		w.compress(file, currentFile)
+	}
+}
@@ -0,0 +1,186 @@
+package rolling
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 降级模式下，积压的数据超过上限，数据被丢弃
+var ErrBacklogFull = errors.New("rolling: backlog is full, data dropped")
+
+// 降级模式
+// 文件无法打开或写入时（通常是磁盘已满、权限错误），进入降级模式：
+//  1. 按退避时间重试打开文件
+//  2. 数据写入备用目录或stderr，都没有配置或写入失败时，保存到内存中
+//  3. 恢复后，先写入内存中积压的数据
+type degradeState struct {
+	degraded      bool          // 是否处于降级模式
+	retryInterval time.Duration // 当前的重试间隔
+	nextRetryTime time.Time     // 下次重试时间
+	fallback      io.Writer     // 备用输出，可能为nil
+	fallbackFile  *os.File      // 备用目录中打开的文件，可能为nil
+	backlog       []*BufferType // 积压的数据
+	backlogSize   int64         // 积压的数据大小
+	droppedCount  int64         // 积压数据超过上限后，丢弃的次数
+}
+
+// 是否处于降级模式
+func (w *RotateWriter) IsDegraded() bool {
+	return w.isDegraded.Load()
+}
+
+// 进入降级模式，已处于降级模式时，延长重试间隔
+func (w *RotateWriter) enterDegraded(err error) {
+	state := &w.degrade
+
+	if state.degraded {
+		state.retryInterval *= 2
+		if state.retryInterval > w.options.RetryMaxInterval {
+			state.retryInterval = w.options.RetryMaxInterval
+		}
+
+	} else {
+		state.degraded = true
+		state.retryInterval = w.options.RetryMinInterval
+		w.isDegraded.Store(true)
+
+		w.Logger().Error("rotate writer enter degraded mode, dir:", w.options.OutDir, "err:", err.Error())
+		w.openFallback()
+	}
+
+	state.nextRetryTime = w.now().Add(state.retryInterval)
+}
+
+// 离开降级模式
+func (w *RotateWriter) leaveDegraded() {
+	state := &w.degrade
+
+	w.Logger().Info("rotate writer recovered from degraded mode, dir:", w.options.OutDir, "dropped:", state.droppedCount)
+
+	if state.fallbackFile != nil {
+		_ = state.fallbackFile.Close()
+	}
+
+	w.degrade = degradeState{}
+	w.isDegraded.Store(false)
+}
+
+// 打开备用输出
+func (w *RotateWriter) openFallback() {
+	state := &w.degrade
+
+	if w.options.FallbackDir != "" {
+		fileName := w.fileName
+		if fileName == "" {
+			baseTime := w.getBaseTime(w.now(), w.options.RotationInterval)
+			fileName = internal.GenerateFileName(w.options.NamePattern, baseTime)
+		}
+
+		file, err := w.openFallbackFile(fileName)
+		if err == nil {
+			state.fallback = file
+			state.fallbackFile = file
+			return
+		}
+
+		w.Logger().Error("open fallback file failed:", err.Error())
+	}
+
+	if w.options.FallbackStderr {
+		state.fallback = os.Stderr
+	}
+}
+
+func (w *RotateWriter) openFallbackFile(fileName string) (*os.File, error) {
+	if err := os.MkdirAll(w.options.FallbackDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fullPath := filepath.Join(w.options.FallbackDir, fileName)
+	return os.OpenFile(fullPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// 降级模式下写入数据
+func (w *RotateWriter) writeDegraded(buff *BufferType) error {
+	state := &w.degrade
+	data := buff.Object().Bytes()
+
+	if state.fallback != nil {
+		if _, err := state.fallback.Write(data); err == nil {
+			return nil
+		}
+	}
+
+	if state.backlogSize+int64(len(data)) > w.options.MaxBacklogSize {
+		state.droppedCount++
+		return ErrBacklogFull
+	}
+
+	buff.IncRef()
+	state.backlog = append(state.backlog, buff)
+	state.backlogSize += int64(len(data))
+
+	return nil
+}
+
+// 尝试恢复，成功时先写入积压的数据
+func (w *RotateWriter) tryRecover() bool {
+	state := &w.degrade
+
+	for len(state.backlog) > 0 {
+		buff := state.backlog[0]
+		written, err := w.writeData(buff.Object().Bytes())
+		if written {
+			// 部分写入时也从积压中移除，避免重复写入
+			state.backlog[0] = nil
+			state.backlog = state.backlog[1:]
+			state.backlogSize -= int64(buff.Object().Len())
+			buff.DecRef()
+		}
+
+		if err != nil {
+			w.enterDegraded(err)
+			return false
+		}
+	}
+
+	// 没有积压数据时，确认文件可以打开
+	if w.writer == nil {
+		if err := w.rotate(0); err != nil {
+			w.enterDegraded(err)
+			return false
+		}
+	}
+
+	w.leaveDegraded()
+	return true
+}
+
+// 关闭时，最后尝试一次恢复，失败则丢弃积压的数据
+func (w *RotateWriter) closeDegraded() {
+	if !w.degrade.degraded {
+		return
+	}
+
+	if w.tryRecover() {
+		return
+	}
+
+	state := &w.degrade
+
+	w.Logger().Error("rotate writer closed in degraded mode, backlog lost:", state.backlogSize, "bytes")
+
+	for _, buff := range state.backlog {
+		buff.DecRef()
+	}
+
+	if state.fallbackFile != nil {
+		_ = state.fallbackFile.Close()
+	}
+
+	w.degrade = degradeState{}
+}
@@ -0,0 +1,168 @@
+package rolling
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tyto/core/logging"
+	"tyto/core/memutil"
+	"tyto/core/rolling/internal"
+)
+
+// 完成标记文件的后缀
+const DONE_SUFFIX = internal.DONE_SUFFIX
+
+// 按小时旋转的事件记录器，每个事件序列化为一行json
+// 文件旋转并关闭后，会创建"文件名.done"标记文件，表示该文件已经写完，可以被读取
+// 进程重启、Close时没有经过旋转的文件，在所在周期结束后的Close或下次启动时创建标记
+type EventRecorder[T any] struct {
+	writer *RotateWriter
+	pool   sync.Pool
+	closed atomic.Bool
+}
+
+// fileName如"purchase.jsonl"，会生成"purchase.jsonl.2006-01-02-15"
+// opts可以覆盖默认的选项
+func NewEventRecorder[T any](logger logging.Logger, outDir string, fileName string, opts ...Option) (*EventRecorder[T], error) {
+	recorder := &EventRecorder[T]{
+		writer: nil,
+		pool:   sync.Pool{New: nil},
+		closed: atomic.Bool{},
+	}
+
+	defaults := []Option{
+		WithOutDir(outDir),
+		WithNamePattern(fileName + ".%F-%H"),
+		WithRotationInterval(time.Hour),
+		WithLogger(logger),
+	}
+
+	// 在用户的回调之前创建完成标记
+	markDone := func(o *internal.Options) {
+		hook := o.OnRotate
+		o.OnRotate = func(closedPath string, newPath string) {
+			recorder.markDone(closedPath)
+			if hook != nil {
+				hook(closedPath, newPath)
+			}
+		}
+	}
+
+	opts = append(append(defaults, opts...), markDone)
+
+	writer, err := NewRotateWriter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder.writer = writer
+	recorder.pool.New = func() interface{} {
+		buff := bytes.Buffer{}
+		buff.Grow(256)
+		return memutil.NewRefObject(buff, recorder.destroyBuffer)
+	}
+
+	// 上次运行时留下的文件没有经过旋转，补上完成标记
+	recorder.markCompleted()
+
+	return recorder, nil
+}
+
+func (r *EventRecorder[T]) destroyBuffer(buff *BufferType) {
+	r.pool.Put(buff)
+}
+
+// 记录事件，序列化失败时不会写入任何数据
+// 每个事件作为一个整体写入，不会出现不完整的行
+func (r *EventRecorder[T]) Record(event T) error {
+	if r.closed.Load() {
+		return os.ErrClosed
+	}
+
+	buff := r.pool.Get().(*BufferType)
+	buff.IncRef()
+	buff.Object().Reset()
+	defer buff.DecRef()
+
+	// Encode会在末尾追加换行符，字符串中的换行符会被转义
+	if err := json.NewEncoder(buff.Object()).Encode(event); err != nil {
+		return err
+	}
+
+	_, err := r.writer.WriteBuffer(buff)
+	return err
+}
+
+// 将缓冲区的数据写入文件
+func (r *EventRecorder[T]) Sync() error {
+	return r.writer.Sync()
+}
+
+// 当前文件所在的周期已经结束时，创建完成标记
+// 否则重启后可能继续写入，等到周期结束后再创建
+func (r *EventRecorder[T]) Close() error {
+	if !r.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	err := r.writer.Close()
+
+	// 写入协程已退出，可以读取当前文件的信息
+	w := r.writer
+	if w.fileName != "" && w.baseName != r.currentBaseName() {
+		r.markDone(filepath.Join(w.options.OutDir, w.fileName))
+	}
+
+	return err
+}
+
+// 获取底层的RotateWriter，用于查询统计信息等
+func (r *EventRecorder[T]) Writer() *RotateWriter {
+	return r.writer
+}
+
+// 当前周期的文件名，不含序号
+func (r *EventRecorder[T]) currentBaseName() string {
+	w := r.writer
+	return internal.GenerateFileName(w.options.NamePattern, w.getBaseTime(w.now(), w.options.RotationInterval))
+}
+
+// 为不属于当前周期、且没有完成标记的文件创建标记
+func (r *EventRecorder[T]) markCompleted() {
+	w := r.writer
+
+	fileList, err := w.listFiles()
+	if err != nil {
+		w.Logger().Error("list file failed:", err.Error())
+		return
+	}
+
+	currentBaseName := r.currentBaseName()
+	for _, file := range fileList {
+		if _, err := os.Stat(file + DONE_SUFFIX); err == nil {
+			continue
+		}
+
+		fileTime, ok := internal.ParseFileTime(w.options.NamePattern, filepath.Base(file), w.options.Location)
+		if !ok || internal.GenerateFileName(w.options.NamePattern, fileTime) == currentBaseName {
+			continue
+		}
+
+		r.markDone(file)
+	}
+}
+
+// 创建完成标记文件
+func (r *EventRecorder[T]) markDone(closedPath string) {
+	file, err := os.OpenFile(closedPath+DONE_SUFFIX, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		r.writer.Logger().Error("create done marker for", closedPath, "failed:", err.Error())
+		return
+	}
+
+	_ = file.Close()
+}
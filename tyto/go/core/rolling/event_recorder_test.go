@@ -0,0 +1,131 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"tyto/core/logs/mini"
+)
+
+type testEvent struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestRecorder(t testing.TB, dir string, clock *FakeClock) *EventRecorder[testEvent] {
+	t.Helper()
+
+	recorder, err := NewEventRecorder[testEvent](mini.NewLogger(), dir, "ev.jsonl",
+		WithLocation(time.UTC),
+		WithClock(clock),
+	)
+	if err != nil {
+		t.Fatal("create recorder failed:", err)
+	}
+
+	t.Cleanup(func() { _ = recorder.Close() })
+
+	return recorder
+}
+
+func mustRecord(t testing.TB, recorder *EventRecorder[testEvent], id int) {
+	t.Helper()
+
+	if err := recorder.Record(testEvent{Id: id, Name: "a\nb"}); err != nil {
+		t.Fatal("record failed:", err)
+	}
+}
+
+func isDone(dir string, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name+DONE_SUFFIX))
+	return err == nil
+}
+
+func TestEventRecorderMarksOnRotate(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC))
+	recorder := newTestRecorder(t, dir, clock)
+
+	// 写入是异步的，推进时钟前等待写入完成
+	mustRecord(t, recorder, 1)
+	if err := recorder.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	mustRecord(t, recorder, 2)
+	if err := recorder.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 回调在单独的协程中执行
+	waitFor(t, "done marker", func() bool {
+		return isDone(dir, "ev.jsonl.2026-01-01-10")
+	})
+
+	if got := readFile(t, filepath.Join(dir, "ev.jsonl.2026-01-01-10")); got != `{"id":1,"name":"a\nb"}`+"\n" {
+		t.Errorf("file = %q", got)
+	}
+	if isDone(dir, "ev.jsonl.2026-01-01-11") {
+		t.Error("current file should not be marked")
+	}
+}
+
+func TestEventRecorderMarksOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC))
+
+	// 周期未结束时关闭，不创建标记
+	recorder := newTestRecorder(t, dir, clock)
+	mustRecord(t, recorder, 1)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if isDone(dir, "ev.jsonl.2026-01-01-10") {
+		t.Fatal("file of the current period should not be marked on Close")
+	}
+
+	// 同一周期内重启，继续写入
+	recorder = newTestRecorder(t, dir, clock)
+	if isDone(dir, "ev.jsonl.2026-01-01-10") {
+		t.Fatal("file of the current period should not be marked on startup")
+	}
+	mustRecord(t, recorder, 2)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 周期结束后重启，启动时创建标记
+	clock.Advance(2 * time.Hour)
+	recorder = newTestRecorder(t, dir, clock)
+	if !isDone(dir, "ev.jsonl.2026-01-01-10") {
+		t.Error("completed file should be marked on startup")
+	}
+	mustRecord(t, recorder, 3)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if isDone(dir, "ev.jsonl.2026-01-01-12") {
+		t.Error("file of the current period should not be marked on Close")
+	}
+}
+
+func TestEventRecorderMarksOnClose(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC))
+	recorder := newTestRecorder(t, dir, clock)
+
+	mustRecord(t, recorder, 1)
+	if err := recorder.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 周期结束后没有新的事件，Close时创建标记
+	clock.Advance(40 * time.Minute)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !isDone(dir, "ev.jsonl.2026-01-01-10") {
+		t.Error("file should be marked on Close after its period ended")
+	}
+}
@@ -0,0 +1,14 @@
+package rolling
+
+import (
+	"tyto/core/rolling/internal"
+)
+
+// 打开文件时的命名方式
+type FileNaming = internal.FileNaming
+
+const (
+	FILE_NAMING_APPEND     = internal.FILE_NAMING_APPEND     // 文件已存在时，从末尾继续写入
+	FILE_NAMING_SEQUENCE   = internal.FILE_NAMING_SEQUENCE   // 每次打开都创建新文件，文件已存在时追加递增的序号
+	FILE_NAMING_START_TIME = internal.FILE_NAMING_START_TIME // 每次打开都创建新文件，文件名追加打开时间
+)
@@ -0,0 +1,88 @@
+package rolling
+
+import (
+	"tyto/core/panicutil"
+	"tyto/core/rolling/internal"
+)
+
+// 文件旋转后的回调，closedPath为已关闭的旧文件，newPath为新文件
+// 开启压缩时，在压缩完成后回调，closedPath为压缩后的文件
+// 启动时压缩的旧文件也会回调，此时newPath可能为空
+type RotateHook = internal.RotateHook
+
+// 清理文件后的回调，removedPath为已删除的文件
+type CleanupHook = internal.CleanupHook
+
+// 生成新文件的文件头，t为文件的创建时间
+type FileHeader = internal.FileHeader
+
+// 旧文件已关闭
+func (w *RotateWriter) onFileClosed(closedPath string, newPath string) {
+	if w.options.Compression != COMPRESSION_NONE {
+		// 压缩完成后回调
+		w.compress(closedPath, newPath)
+		return
+	}
+
+	w.notifyRotate(closedPath, newPath)
+}
+
+func (w *RotateWriter) notifyRotate(closedPath string, newPath string) {
+	hook := w.options.OnRotate
+	if hook == nil {
+		return
+	}
+
+	w.enqueueHook(func() {
+		hook(closedPath, newPath)
+	})
+}
+
+func (w *RotateWriter) notifyCleanup(removedPath string) {
+	hook := w.options.OnCleanup
+	if hook == nil {
+		return
+	}
+
+	w.enqueueHook(func() {
+		hook(removedPath)
+	})
+}
+
+// 回调在单独的协程中按顺序执行，避免耗时的回调阻塞写入
+func (w *RotateWriter) enqueueHook(f func()) {
+	w.hookMutex.Lock()
+	defer w.hookMutex.Unlock()
+
+	w.hookQueue = append(w.hookQueue, f)
+
+	if w.hookRunning {
+		return
+	}
+
+	w.hookRunning = true
+	go w.runHooks()
+}
+
+func (w *RotateWriter) runHooks() {
+	for {
+		w.hookMutex.Lock()
+		if len(w.hookQueue) == 0 {
+			w.hookRunning = false
+			w.hookMutex.Unlock()
+			return
+		}
+
+		f := w.hookQueue[0]
+		w.hookQueue[0] = nil
+		w.hookQueue = w.hookQueue[1:]
+		w.hookMutex.Unlock()
+
+		w.callHook(f)
+	}
+}
+
+func (w *RotateWriter) callHook(f func()) {
+	defer panicutil.Recover(w)
+	f()
+}
@@ -2,19 +2,22 @@ package internal
 
 import (
 	"bufio"
+	"net"
 	"os"
 )
 
 // 有缓冲
 type BufferedWriter struct {
-	file *os.File      // 当前的输出文件
-	buff *bufio.Writer // buffer io时，使用的writer
+	file  *os.File      // 当前的输出文件
+	buff  *bufio.Writer // buffer io时，使用的writer
+	fsync bool          // 关闭、重置文件时，是否将数据同步到磁盘
 }
 
-func NewBufferedWriter(file *os.File, bufferSize int32) Writer {
+func NewBufferedWriter(file *os.File, bufferSize int32, fsync bool) Writer {
 	return &BufferedWriter{
-		file: file,
-		buff: bufio.NewWriterSize(file, int(bufferSize)),
+		file:  file,
+		buff:  bufio.NewWriterSize(file, int(bufferSize)),
+		fsync: fsync,
 	}
 }
 
@@ -26,17 +29,30 @@ func (writer *BufferedWriter) WriteString(s string) (n int, err error) {
 	return writer.buff.WriteString(s)
 }
 
+func (writer *BufferedWriter) WriteBuffers(bufs net.Buffers) (int64, error) {
+	return bufs.WriteTo(writer.buff)
+}
+
 func (writer *BufferedWriter) IsBuffered() bool {
 	return true
 }
 
+func (writer *BufferedWriter) Flush() error {
+	return writer.buff.Flush()
+}
+
 func (writer *BufferedWriter) Sync() error {
-	writer.buff.Flush()
+	if err := writer.buff.Flush(); err != nil {
+		return err
+	}
 	return writer.file.Sync()
 }
 
 func (writer *BufferedWriter) Close() error {
-	writer.Sync()
+	writer.buff.Flush()
+	if writer.fsync {
+		writer.file.Sync()
+	}
 	return writer.file.Close()
 }
 
@@ -44,13 +60,9 @@ func (writer *BufferedWriter) Reset(file *os.File) {
 	writer.buff.Flush()
 	writer.buff.Reset(file)
 
-	writer.file.Sync()
+	if writer.fsync {
+		writer.file.Sync()
+	}
 	writer.file.Close()
 	writer.file = file
 }
-
-// file对象指向的文件是否已经被删除
-func (writer *BufferedWriter) IsValid() bool {
-	_, err := writer.file.Stat()
-	return err == nil
-}
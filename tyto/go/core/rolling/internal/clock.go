@@ -0,0 +1,42 @@
+package internal
+
+import "time"
+
+// 定时器，与time.Ticker的行为一致
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// 时钟，用于获取当前时间、创建定时器
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// 系统时钟
+type systemClock struct{}
+
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return &systemTicker{ticker: time.NewTicker(d)}
+}
+
+func (t *systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *systemTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// 压缩方式
+type Compression int32
+
+const (
+	COMPRESSION_NONE Compression = 0 // 不压缩
+	COMPRESSION_GZIP Compression = 1 // gzip
+)
+
+const (
+	GZIP_SUFFIX = ".gz"  // gzip压缩文件后缀
+	TEMP_SUFFIX = ".tmp" // 压缩过程中的临时文件后缀
+)
+
+// 压缩文件的后缀
+func (c Compression) Suffix() string {
+	switch c {
+	case COMPRESSION_GZIP:
+		return GZIP_SUFFIX
+	default:
+		return ""
+	}
+}
+
+// 去掉压缩文件的后缀
+func TrimCompressionSuffix(name string) string {
+	return strings.TrimSuffix(name, GZIP_SUFFIX)
+}
+
+// 是否为压缩过程中的临时文件
+func IsTempFile(name string) bool {
+	return strings.HasSuffix(name, TEMP_SUFFIX)
+}
+
+// 将path压缩为path.gz，成功后删除path
+// 压缩文件落盘(fsync)之后才会删除原文件，压缩文件的修改时间与原文件保持一致
+func GzipFile(path string, level int) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstPath := path + GZIP_SUFFIX
+	tempPath := dstPath + TEMP_SUFFIX
+
+	dst, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err = gzipCopy(dst, src, level); err == nil {
+		err = dst.Sync()
+	}
+
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	if err = os.Rename(tempPath, dstPath); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	// 保持修改时间，避免影响过期清理
+	_ = os.Chtimes(dstPath, srcInfo.ModTime(), srcInfo.ModTime())
+
+	return os.Remove(path)
+}
+
+func gzipCopy(dst io.Writer, src io.Reader, level int) error {
+	zw, err := gzip.NewWriterLevel(dst, level)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(zw, src); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -9,8 +9,8 @@ type EventType int32
 
 const (
 	EVENT_TYPE_SYNC   EventType = 1
-	EVENT_TYPE_CLOSE  EventType = 2
 	EVENT_TYPE_BUFFER EventType = 3
+	EVENT_TYPE_REOPEN EventType = 4
 )
 
 type Event struct {
@@ -0,0 +1,18 @@
+package internal
+
+// 打开文件时的命名方式
+type FileNaming int32
+
+const (
+	FILE_NAMING_APPEND     FileNaming = 0 // 文件已存在时，从末尾继续写入
+	FILE_NAMING_SEQUENCE   FileNaming = 1 // 每次打开都创建新文件，文件已存在时追加递增的序号，如".1"、".2"
+	FILE_NAMING_START_TIME FileNaming = 2 // 每次打开都创建新文件，文件名追加打开时间，如".20060102T150405"
+)
+
+// FILE_NAMING_START_TIME追加的时间格式
+const START_TIME_LAYOUT = "20060102T150405"
+
+// 是否每次打开都创建新文件
+func (n FileNaming) IsFresh() bool {
+	return n != FILE_NAMING_APPEND
+}
@@ -2,36 +2,175 @@ package internal
 
 import (
 	"github.com/itchyny/timefmt-go"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
-func GetBaseTime(t time.Time, interval time.Duration) time.Time {
-	if t.Location() != time.UTC {
-		// 转换为UTC时间
-		year, month, day := t.Date()
-		hour, min, sec := t.Clock()
-		nsec := t.Nanosecond()
-		baseTime := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+// 计算t所在周期的开始时间
+// 周期按loc时区的本地时间划分，并向后偏移offset，如interval为24小时、offset为5小时时，
+// 每天的05:00为周期的开始时间
+// 夏令时切换的当天，周期仍然从本地时间的05:00开始，周期的实际长度可能为23或25小时
+// 夏令时结束、本地时间重复时，取不晚于t的那个时刻
+func GetBaseTime(t time.Time, interval time.Duration, offset time.Duration, loc *time.Location) time.Time {
+	t = t.In(loc)
 
-		// 非utc时间进行Truncate操作会带上时区信息，所以需要手动去掉
-		baseTime = baseTime.Truncate(interval)
+	// 在本地时间上进行Truncate操作，直接对非utc时间Truncate会以utc时间划分周期
+	wall := toWallClock(t)
+	wall = wall.Add(-offset).Truncate(interval).Add(offset)
 
-		// 还原为原始时区
-		year, month, day = baseTime.Date()
-		hour, min, sec = baseTime.Clock()
-		nsec = baseTime.Nanosecond()
+	times := wallClockTimes(wall, loc)
+	if len(times) == 0 {
+		return skippedWallClock(wall, loc)
+	}
+
+	for i := len(times) - 1; i > 0; i-- {
+		if !times[i].After(t) {
+			return times[i]
+		}
+	}
+
+	return times[0]
+}
+
+// 计算下一个周期的开始时间，baseTime为GetBaseTime的返回值
+// 夏令时结束、本地时间回拨到周期的开始时间时，提前结束当前周期，
+// 因此不超过1小时的周期，实际长度不变；更长的周期包含重复的本地时间，实际长度增加
+// 本地时间相同的两个周期生成的文件名相同，会写入同一个文件
+func GetNextTime(baseTime time.Time, interval time.Duration, offset time.Duration) time.Time {
+	loc := baseTime.Location()
+
+	// baseTime可能因夏令时而偏离周期的划分，需要重新对齐
+	wall := toWallClock(baseTime)
+	wall = wall.Add(-offset).Truncate(interval).Add(offset).Add(interval)
+
+	next := skippedWallClock(wall, loc)
+	for _, t := range wallClockTimes(wall, loc) {
+		if t.After(baseTime) {
+			next = t
+			break
+		}
+	}
+
+	// 周期内本地时间回拨时，回拨后的第一个周期边界同样是下一个周期的开始时间
+	_, end := baseTime.ZoneBounds()
+	if end.IsZero() || !end.Before(next) {
+		return next
+	}
+
+	_, offsetBefore := baseTime.Zone()
+	_, offsetAfter := end.Zone()
+	if offsetAfter >= offsetBefore {
+		return next
+	}
+
+	wallAfter := toWallClock(end)
+	boundary := wallAfter.Add(-offset).Truncate(interval).Add(offset)
+	if boundary.Before(wallAfter) {
+		boundary = boundary.Add(interval)
+	}
+
+	if t := end.Add(boundary.Sub(wallAfter)); t.Before(next) {
+		return t
+	}
+
+	return next
+}
+
+// 将本地时间的各个字段原样转换为utc时间
+func toWallClock(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), time.UTC)
+}
+
+// 返回loc时区中，本地时间为wall（toWallClock的结果）的所有时刻，从早到晚排序
+// 夏令时结束时，同一个本地时间会出现两次；夏令时开始时，被跳过的本地时间不存在，返回空
+func wallClockTimes(wall time.Time, loc *time.Location) []time.Time {
+	year, month, day := wall.Date()
+	hour, min, sec := wall.Clock()
+	guess := time.Date(year, month, day, hour, min, sec, wall.Nanosecond(), loc)
+
+	// 可能的时区偏移：guess所在时区，以及前后相邻的时区
+	_, guessOffset := guess.Zone()
+	offsets := []int{guessOffset}
+	start, end := guess.ZoneBounds()
+	if !start.IsZero() {
+		_, before := start.Add(-time.Nanosecond).Zone()
+		offsets = append(offsets, before)
+	}
+	if !end.IsZero() {
+		_, after := end.Zone()
+		offsets = append(offsets, after)
+	}
+
+	times := make([]time.Time, 0, 2)
+	for _, offset := range offsets {
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if !toWallClock(t).Equal(wall) {
+			continue
+		}
+
+		if !slices.ContainsFunc(times, t.Equal) {
+			times = append(times, t)
+		}
+	}
+
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	return times
+}
+
+// 本地时间不存在时（夏令时开始），返回时钟跳过该时间的时刻
+func skippedWallClock(wall time.Time, loc *time.Location) time.Time {
+	year, month, day := wall.Date()
+	hour, min, sec := wall.Clock()
+	t := time.Date(year, month, day, hour, min, sec, wall.Nanosecond(), loc)
+
+	// time.Date对不存在的时间进行归一化时，结果可能早于或晚于跳变的时刻
+	actual := toWallClock(t)
+	if actual.Before(wall) {
+		if _, end := t.ZoneBounds(); !end.IsZero() {
+			t = end
+		}
 
-		return time.Date(year, month, day, hour, min, sec, nsec, t.Location())
+	} else if actual.After(wall) {
+		if start, _ := t.ZoneBounds(); !start.IsZero() {
+			t = start
+		}
 	}
 
-	return t.Truncate(interval)
+	return t
 }
 
 func GenerateFileName(format string, t time.Time) string {
 	return timefmt.Format(t, format)
 }
 
+// 从文件名中解析出生成文件名时使用的时间，文件名可以带有序号、打开时间、压缩后缀
+// 只能解析出namePattern中包含的字段，如"%F"只能得到当天的零点
+func ParseFileTime(namePattern string, name string, loc *time.Location) (time.Time, bool) {
+	name = TrimCompressionSuffix(name)
+
+	// 依次去掉序号、打开时间
+	for i := 0; i < 3; i++ {
+		if t, err := timefmt.ParseInLocation(name, namePattern, loc); err == nil {
+			return t, true
+		}
+
+		index := strings.LastIndexByte(name, '.')
+		if index < 0 {
+			break
+		}
+		name = name[:index]
+	}
+
+	return time.Time{}, false
+}
+
 func ToGlobPattern(pattern string) string {
 	// 转换表
 	var conversionList = []*regexp.Regexp{
@@ -48,3 +187,82 @@ func ToGlobPattern(pattern string) string {
 
 	return globPattern
 }
+
+// 在文件名后追加序号，序号为0时不追加
+func AppendIndex(fileName string, index int32) string {
+	if index <= 0 {
+		return fileName
+	}
+
+	return fileName + "." + strconv.Itoa(int(index))
+}
+
+// 解析文件名后追加的序号，不是fileName的带序号文件时返回false
+// 已压缩的文件同样可以解析
+func ParseIndex(fileName string, path string) (int32, bool) {
+	name := TrimCompressionSuffix(filepath.Base(path))
+	if !strings.HasPrefix(name, fileName+".") {
+		return 0, false
+	}
+
+	index, err := strconv.ParseInt(name[len(fileName)+1:], 10, 32)
+	if err != nil || index <= 0 {
+		return 0, false
+	}
+
+	return int32(index), true
+}
+
+// 完成标记文件的后缀，标记文件不会被当作日志文件，随日志文件一起删除
+const DONE_SUFFIX = ".done"
+
+// 是否为完成标记文件
+func IsDoneFile(name string) bool {
+	return strings.HasSuffix(name, DONE_SUFFIX)
+}
+
+// 在fileList中查找fileName的最大序号，不存在带序号的文件时返回0
+func FindLastIndex(fileList []string, fileName string) int32 {
+	last := int32(0)
+	for _, file := range fileList {
+		if index, ok := ParseIndex(fileName, file); ok && index > last {
+			last = index
+		}
+	}
+
+	return last
+}
+
+// 列出dir目录下所有由globPattern生成的文件，包括追加了序号、压缩后缀的文件
+// 不包括压缩过程中的临时文件、完成标记文件
+func ListFiles(dir string, globPattern string) ([]string, error) {
+	patterns := []string{
+		globPattern,
+		globPattern + ".*",
+	}
+
+	fileList := make([]string, 0, 16)
+	visited := make(map[string]struct{})
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range matches {
+			if _, ok := visited[file]; ok {
+				continue
+			}
+
+			if IsTempFile(file) || IsDoneFile(file) {
+				continue
+			}
+
+			visited[file] = struct{}{}
+			fileList = append(fileList, file)
+		}
+	}
+
+	return fileList, nil
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestGetBaseTimeAndNextTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc8 := time.FixedZone("UTC+8", 8*60*60)
+
+	utc := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		offset   time.Duration
+		loc      *time.Location
+		base     time.Time
+		next     time.Time
+	}{
+		// 05:00开始的业务日
+		{"offset before reset", utc("2026-01-02T04:59:59Z"), 24 * time.Hour, 5 * time.Hour, time.UTC,
+			utc("2026-01-01T05:00:00Z"), utc("2026-01-02T05:00:00Z")},
+		{"offset at reset", utc("2026-01-02T05:00:00Z"), 24 * time.Hour, 5 * time.Hour, time.UTC,
+			utc("2026-01-02T05:00:00Z"), utc("2026-01-03T05:00:00Z")},
+
+		// 固定时区，按UTC+8的零点划分
+		{"fixed zone", utc("2026-01-01T20:00:00Z"), 24 * time.Hour, 0, utc8,
+			utc("2026-01-01T16:00:00Z"), utc("2026-01-02T16:00:00Z")},
+		{"fixed zone with offset", utc("2026-01-01T22:00:00Z"), 24 * time.Hour, 5 * time.Hour, utc8,
+			utc("2026-01-01T21:00:00Z"), utc("2026-01-02T21:00:00Z")},
+
+		// 夏令时开始：2026-03-08 02:00 EST跳到03:00 EDT，当天只有23小时
+		{"spring forward day", utc("2026-03-08T12:00:00Z"), 24 * time.Hour, 0, newYork,
+			utc("2026-03-08T05:00:00Z"), utc("2026-03-09T04:00:00Z")},
+		{"spring forward offset skipped, before jump", utc("2026-03-08T06:30:00Z"), 24 * time.Hour, 150 * time.Minute, newYork,
+			utc("2026-03-07T07:30:00Z"), utc("2026-03-08T07:00:00Z")},
+		{"spring forward offset skipped, after jump", utc("2026-03-08T12:00:00Z"), 24 * time.Hour, 150 * time.Minute, newYork,
+			utc("2026-03-08T07:00:00Z"), utc("2026-03-09T06:30:00Z")},
+		{"spring forward hourly", utc("2026-03-08T06:30:00Z"), time.Hour, 0, newYork,
+			utc("2026-03-08T06:00:00Z"), utc("2026-03-08T07:00:00Z")},
+
+		// 夏令时结束：2026-11-01 02:00 EDT回拨到01:00 EST，当天有25小时
+		{"fall back day", utc("2026-11-01T12:00:00Z"), 24 * time.Hour, 0, newYork,
+			utc("2026-11-01T04:00:00Z"), utc("2026-11-02T05:00:00Z")},
+		{"fall back 05:00 offset", utc("2026-11-01T08:00:00Z"), 24 * time.Hour, 5 * time.Hour, newYork,
+			utc("2026-10-31T09:00:00Z"), utc("2026-11-01T10:00:00Z")},
+		{"fall back hourly, first 01:00", utc("2026-11-01T05:30:00Z"), time.Hour, 0, newYork,
+			utc("2026-11-01T05:00:00Z"), utc("2026-11-01T06:00:00Z")},
+		{"fall back hourly, second 01:00", utc("2026-11-01T06:30:00Z"), time.Hour, 0, newYork,
+			utc("2026-11-01T06:00:00Z"), utc("2026-11-01T07:00:00Z")},
+		{"fall back half hour, first 01:30", utc("2026-11-01T05:45:00Z"), 30 * time.Minute, 0, newYork,
+			utc("2026-11-01T05:30:00Z"), utc("2026-11-01T06:00:00Z")},
+		{"fall back half hour, second 01:00", utc("2026-11-01T06:10:00Z"), 30 * time.Minute, 0, newYork,
+			utc("2026-11-01T06:00:00Z"), utc("2026-11-01T06:30:00Z")},
+		{"fall back two hours", utc("2026-11-01T06:30:00Z"), 2 * time.Hour, 0, newYork,
+			utc("2026-11-01T04:00:00Z"), utc("2026-11-01T07:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := GetBaseTime(tt.now, tt.interval, tt.offset, tt.loc)
+			if !base.Equal(tt.base) {
+				t.Errorf("GetBaseTime = %v, want %v", base.UTC(), tt.base)
+			}
+			if base.After(tt.now) {
+				t.Errorf("GetBaseTime = %v is after now %v", base.UTC(), tt.now)
+			}
+
+			next := GetNextTime(base, tt.interval, tt.offset)
+			if !next.Equal(tt.next) {
+				t.Errorf("GetNextTime = %v, want %v", next.UTC(), tt.next)
+			}
+			if !next.After(tt.now) {
+				t.Errorf("GetNextTime = %v is not after now %v", next.UTC(), tt.now)
+			}
+		})
+	}
+}
+
+// 逐分钟推进，周期必须首尾相接，且now总是落在[base, next)内
+func TestPeriodsAreContiguous(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ranges := []time.Time{
+		time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC),
+	}
+	intervals := []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour, 24 * time.Hour}
+	offsets := []time.Duration{0, 150 * time.Minute, 5 * time.Hour}
+
+	for _, start := range ranges {
+		for _, interval := range intervals {
+			for _, offset := range offsets {
+				offset %= interval
+
+				base := GetBaseTime(start, interval, offset, newYork)
+				next := GetNextTime(base, interval, offset)
+				for now := start; now.Before(start.Add(72 * time.Hour)); now = now.Add(time.Minute) {
+					if !now.Before(next) {
+						base, next = next, GetNextTime(next, interval, offset)
+						if got := GetBaseTime(now, interval, offset, newYork); !got.Equal(base) {
+							t.Fatalf("interval %v offset %v: GetBaseTime(%v) = %v, want %v",
+								interval, offset, now.UTC(), got.UTC(), base.UTC())
+						}
+					}
+					if now.Before(base) || !now.Before(next) {
+						t.Fatalf("interval %v offset %v: %v not in [%v, %v)",
+							interval, offset, now.UTC(), base.UTC(), next.UTC())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestParseFileTime(t *testing.T) {
+	want := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	for _, name := range []string{
+		"app.log.2026-01-02-15",
+		"app.log.2026-01-02-15.3",
+		"app.log.2026-01-02-15.gz",
+		"app.log.2026-01-02-15.20260102T153000",
+		"app.log.2026-01-02-15.20260102T153000.2.gz",
+	} {
+		got, ok := ParseFileTime("app.log.%F-%H", name, time.UTC)
+		if !ok || !got.Equal(want) {
+			t.Errorf("ParseFileTime(%q) = %v, %v, want %v", name, got, ok, want)
+		}
+	}
+
+	if _, ok := ParseFileTime("app.log.%F-%H", "app.log", time.UTC); ok {
+		t.Error("ParseFileTime should fail for a name without time")
+	}
+}
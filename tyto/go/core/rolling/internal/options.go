@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"compress/gzip"
 	"errors"
 	"path/filepath"
 	"strings"
@@ -15,35 +16,102 @@ const (
 	DEFAULT_WRITER_QUEUE_SIZE = 1024                // 默认写入队列大小
 	DEFAULT_BUFFER_SIZE       = 64 * 1024           // 默认缓冲区大小
 	DEFAULT_FLUSH_INTERVAL    = 5 * time.Second     // 默认缓冲区刷到文件的时间间隔
+	DEFAULT_RETRY_MIN         = 1 * time.Second     // 默认降级模式下的最小重试间隔
+	DEFAULT_RETRY_MAX         = 1 * time.Minute     // 默认降级模式下的最大重试间隔
+	DEFAULT_MAX_BACKLOG_SIZE  = 4 * 1024 * 1024     // 默认降级模式下内存中积压数据的上限
+	DEFAULT_CHECK_INTERVAL    = 1 * time.Second     // 默认检查文件是否被移动的时间间隔
 )
 
+// 文件旋转后的回调
+type RotateHook func(closedPath string, newPath string)
+
+// 清理文件后的回调
+type CleanupHook func(removedPath string)
+
+// 生成新文件的文件头，t为文件的创建时间
+type FileHeader func(fileName string, t time.Time) []byte
+
 // 旋转文件选项
 type Options struct {
-	OutDir           string         // 文件输出目录
-	NamePattern      string         // 文件名生成模式
-	LinkName         string         // 文件符号链接名，用于给当前正在写入的文件创建一个符号链接
-	MaxAge           time.Duration  // 文件保留时间，<=0表示不清理
-	CleanupInterval  time.Duration  // 清理过期文件的时间间隔
-	RotationInterval time.Duration  // 文件轮转间隔
-	WriterQueueSize  int32          // 写入队列大小，会自动扩容
-	BufferSize       int32          // 写入缓冲区大小
-	FlushInterval    time.Duration  // 缓冲区刷到文件的时间间隔
-	Logger           logging.Logger // 日志器
+	OutDir            string         // 文件输出目录
+	NamePattern       string         // 文件名生成模式
+	LinkName          string         // 文件符号链接名，用于给当前正在写入的文件创建一个符号链接
+	ArchiveDir        string         // 归档目录生成模式，相对于OutDir，为空表示不归档
+	MaxAge            time.Duration  // 文件保留时间，<=0表示不清理
+	MaxBackups        int32          // 保留的旧文件数量，不含当前文件，<=0表示不限制
+	MaxTotalSize      int64          // 所有文件的总字节数，含当前文件，<=0表示不限制
+	CleanupInterval   time.Duration  // 清理过期文件的时间间隔
+	RotationInterval  time.Duration  // 文件轮转间隔
+	RotationOffset    time.Duration  // 周期开始时间的偏移，旋转和清理的时间都会偏移
+	Location          *time.Location // 划分周期、生成文件名使用的时区
+	WriterQueueSize   int32          // 写入队列大小，会自动扩容
+	LockFreeQueue     bool           // 写入队列是否使用无锁队列
+	MaxQueueSize      int32          // 写入队列的最大长度，<=0表示不限制
+	QueuePolicy       QueuePolicy    // 写入队列已满时的处理策略
+	BufferSize        int32          // 写入缓冲区大小
+	FlushInterval     time.Duration  // 缓冲区刷到文件的时间间隔，SYNC_POLICY_INTERVAL时也是fsync的间隔
+	SyncPolicy        SyncPolicy     // 同步到磁盘的策略
+	SyncBytes         int64          // SYNC_POLICY_EVERY_N_BYTES策略下，每写入多少字节同步一次
+	MaxSize           int64          // 单个文件最大字节数，<=0表示不限制
+	FileNaming        FileNaming     // 打开文件时的命名方式
+	Compression       Compression    // 旋转后的文件压缩方式
+	CompressionLevel  int            // 压缩级别
+	RetryMinInterval  time.Duration  // 降级模式下的最小重试间隔
+	RetryMaxInterval  time.Duration  // 降级模式下的最大重试间隔
+	FallbackDir       string         // 降级模式下的备用目录，为空表示不使用
+	FallbackStderr    bool           // 降级模式下是否输出到stderr
+	MaxBacklogSize    int64          // 降级模式下内存中积压数据的上限，<=0表示不积压
+	FileCheckInterval time.Duration  // 写入时检查文件是否被移动或删除的时间间隔，<=0表示只在刷新时检查
+	ReopenOnSignal    bool           // 收到SIGUSR1信号时重新打开文件
+	EagerOpen         bool           // 创建时立即打开文件，而不是等到第一次写入
+	OnRotate          RotateHook     // 文件旋转后的回调
+	OnCleanup         CleanupHook    // 清理文件后的回调
+	FileHeader        FileHeader     // 新文件的文件头，为nil表示不写入
+	Clock             Clock          // 时钟
+	Scheduler         *Scheduler     // 共享的I/O调度器，为nil表示使用单独的协程
+	Logger            logging.Logger // 日志器
 }
 
 // 新建旋转文件选项
 func NewDefaultOptions() *Options {
 	return &Options{
-		OutDir:           "",
-		NamePattern:      "",
-		LinkName:         "",
-		MaxAge:           DEFAULT_MAX_AGE,
-		CleanupInterval:  DEFAULT_CLEANUP_INTERVAL,
-		RotationInterval: DEFAULT_ROTATION_INTERVAL,
-		WriterQueueSize:  DEFAULT_WRITER_QUEUE_SIZE,
-		BufferSize:       DEFAULT_BUFFER_SIZE,
-		FlushInterval:    DEFAULT_FLUSH_INTERVAL,
-		Logger:           nil,
+		OutDir:            "",
+		NamePattern:       "",
+		LinkName:          "",
+		ArchiveDir:        "",
+		MaxAge:            DEFAULT_MAX_AGE,
+		MaxBackups:        0,
+		MaxTotalSize:      0,
+		CleanupInterval:   DEFAULT_CLEANUP_INTERVAL,
+		RotationInterval:  DEFAULT_ROTATION_INTERVAL,
+		RotationOffset:    0,
+		Location:          time.Local,
+		WriterQueueSize:   DEFAULT_WRITER_QUEUE_SIZE,
+		LockFreeQueue:     false,
+		MaxQueueSize:      0,
+		QueuePolicy:       QUEUE_POLICY_BLOCK,
+		BufferSize:        DEFAULT_BUFFER_SIZE,
+		FlushInterval:     DEFAULT_FLUSH_INTERVAL,
+		SyncPolicy:        SYNC_POLICY_INTERVAL,
+		SyncBytes:         0,
+		MaxSize:           0,
+		FileNaming:        FILE_NAMING_APPEND,
+		Compression:       COMPRESSION_NONE,
+		CompressionLevel:  gzip.DefaultCompression,
+		RetryMinInterval:  DEFAULT_RETRY_MIN,
+		RetryMaxInterval:  DEFAULT_RETRY_MAX,
+		FallbackDir:       "",
+		FallbackStderr:    false,
+		MaxBacklogSize:    DEFAULT_MAX_BACKLOG_SIZE,
+		FileCheckInterval: DEFAULT_CHECK_INTERVAL,
+		ReopenOnSignal:    false,
+		EagerOpen:         false,
+		OnRotate:          nil,
+		OnCleanup:         nil,
+		FileHeader:        nil,
+		Clock:             NewSystemClock(),
+		Scheduler:         nil,
+		Logger:            nil,
 	}
 }
 
@@ -66,6 +134,10 @@ func (o *Options) Validate() error {
 		return errors.New("NamePattern format error")
 	}
 
+	if o.ArchiveDir != "" && (!filepath.IsLocal(o.ArchiveDir) || strings.ContainsAny(o.ArchiveDir, "*$")) {
+		return errors.New("ArchiveDir format error")
+	}
+
 	if o.CleanupInterval <= 0 {
 		return errors.New("CleanupInterval must be greater than 0")
 	}
@@ -74,12 +146,60 @@ func (o *Options) Validate() error {
 		return errors.New("RotationInterval must be greater than 0")
 	}
 
+	switch o.QueuePolicy {
+	case QUEUE_POLICY_BLOCK, QUEUE_POLICY_DROP, QUEUE_POLICY_DROP_AND_COUNT:
+	default:
+		return errors.New("QueuePolicy is not supported")
+	}
+
+	switch o.SyncPolicy {
+	case SYNC_POLICY_NONE, SYNC_POLICY_INTERVAL, SYNC_POLICY_EVERY_WRITE:
+	case SYNC_POLICY_EVERY_N_BYTES:
+		if o.SyncBytes <= 0 {
+			return errors.New("SyncBytes must be greater than 0 when SyncPolicy is SYNC_POLICY_EVERY_N_BYTES")
+		}
+	default:
+		return errors.New("SyncPolicy is not supported")
+	}
+
+	switch o.FileNaming {
+	case FILE_NAMING_APPEND, FILE_NAMING_SEQUENCE, FILE_NAMING_START_TIME:
+	default:
+		return errors.New("FileNaming is not supported")
+	}
+
+	if o.Location == nil {
+		return errors.New("Location is required")
+	}
+
 	if o.BufferSize < 0 {
 		return errors.New("BufferSize must be greater than or equal to 0")
 	}
 
-	if o.BufferSize > 0 && o.FlushInterval <= 0 {
-		return errors.New("FlushInterval must be greater than 0 when BufferSize is greater than 0")
+	if (o.BufferSize > 0 || o.SyncPolicy == SYNC_POLICY_INTERVAL) && o.FlushInterval <= 0 {
+		return errors.New("FlushInterval must be greater than 0 when BufferSize is greater than 0 or SyncPolicy is SYNC_POLICY_INTERVAL")
+	}
+
+	if o.RetryMinInterval <= 0 {
+		return errors.New("RetryMinInterval must be greater than 0")
+	}
+
+	if o.RetryMaxInterval < o.RetryMinInterval {
+		return errors.New("RetryMaxInterval must be greater than or equal to RetryMinInterval")
+	}
+
+	switch o.Compression {
+	case COMPRESSION_NONE:
+	case COMPRESSION_GZIP:
+		if o.CompressionLevel < gzip.HuffmanOnly || o.CompressionLevel > gzip.BestCompression {
+			return errors.New("CompressionLevel is invalid for gzip")
+		}
+	default:
+		return errors.New("Compression is not supported")
+	}
+
+	if o.Clock == nil {
+		return errors.New("Clock is required")
 	}
 
 	if o.Logger == nil {
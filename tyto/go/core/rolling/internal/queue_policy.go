@@ -0,0 +1,10 @@
+package internal
+
+// 写入队列已满时的处理策略
+type QueuePolicy int32
+
+const (
+	QUEUE_POLICY_BLOCK          QueuePolicy = 0 // 阻塞调用者，直到队列有空位
+	QUEUE_POLICY_DROP           QueuePolicy = 1 // 丢弃新写入的数据
+	QUEUE_POLICY_DROP_AND_COUNT QueuePolicy = 2 // 丢弃新写入的数据，并统计丢弃次数
+)
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+	"tyto/core/syncutil"
+)
+
+// 每次调度最多处理的事件数量，避免单个任务长时间占用工作协程
+const SCHEDULER_BATCH_SIZE = 64
+
+// 由Scheduler调度的任务
+type Task interface {
+	// 处理最多batchSize个事件，返回false表示任务已结束
+	Run(batchSize int) bool
+	// 是否还有待处理的事件，可能与Run并发调用，不能读取只属于消费者的状态
+	Pending() bool
+	// 定时刷新，由定时器协程调用，不能阻塞
+	Flush()
+}
+
+// 已加入Scheduler的任务
+// 同一时间只会被一个工作协程执行，保证任务内事件的顺序
+type ScheduledTask struct {
+	scheduler *Scheduler
+	task      Task
+	scheduled atomic.Bool // 是否已在就绪队列中或正在执行
+	detached  atomic.Bool
+}
+
+// 多个任务共享固定数量的工作协程和一个刷新定时器
+type Scheduler struct {
+	ready   *syncutil.WaitQueue[*ScheduledTask]
+	mutex   sync.Mutex
+	tasks   map[*ScheduledTask]struct{}
+	ticker  Ticker
+	done    chan struct{}
+	wg      sync.WaitGroup
+	workers int32
+	closed  atomic.Bool
+}
+
+func NewScheduler(workers int32, flushInterval time.Duration, clock Clock) *Scheduler {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	s := &Scheduler{
+		ready:   syncutil.NewWaitQueue[*ScheduledTask](64),
+		mutex:   sync.Mutex{},
+		tasks:   make(map[*ScheduledTask]struct{}),
+		ticker:  clock.NewTicker(flushInterval),
+		done:    make(chan struct{}),
+		wg:      sync.WaitGroup{},
+		workers: workers,
+		closed:  atomic.Bool{},
+	}
+
+	s.wg.Add(int(workers) + 1)
+	for i := int32(0); i < workers; i++ {
+		go s.runWorker()
+	}
+	go s.runTicker()
+
+	return s
+}
+
+// 加入任务
+func (s *Scheduler) Attach(task Task) *ScheduledTask {
+	t := &ScheduledTask{
+		scheduler: s,
+		task:      task,
+		scheduled: atomic.Bool{},
+		detached:  atomic.Bool{},
+	}
+
+	s.mutex.Lock()
+	s.tasks[t] = struct{}{}
+	s.mutex.Unlock()
+
+	return t
+}
+
+// 停止所有工作协程和定时器，同步阻塞
+// 需要先关闭所有加入的任务，否则未处理的事件会被丢弃
+func (s *Scheduler) Close() {
+	if !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	s.ticker.Stop()
+	close(s.done)
+
+	// 每个工作协程收到一个nil后退出
+	for i := int32(0); i < s.workers; i++ {
+		s.ready.Push(nil)
+	}
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) detach(t *ScheduledTask) {
+	t.detached.Store(true)
+
+	s.mutex.Lock()
+	delete(s.tasks, t)
+	s.mutex.Unlock()
+}
+
+func (s *Scheduler) runWorker() {
+	defer s.wg.Done()
+
+	for {
+		t := s.ready.Pop()
+		if t == nil {
+			return
+		}
+
+		if !t.task.Run(SCHEDULER_BATCH_SIZE) {
+			s.detach(t)
+			continue
+		}
+
+		t.scheduled.Store(false)
+
+		// 执行期间加入的事件可能未能触发调度，重新放入队尾，让其他任务有机会执行
+		// 清除标记后，任务可能已被其他工作协程执行，因此Pending需要是并发安全的
+		if t.task.Pending() {
+			t.Schedule()
+		}
+	}
+}
+
+func (s *Scheduler) runTicker() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ticker.C():
+			s.mutex.Lock()
+			tasks := make([]*ScheduledTask, 0, len(s.tasks))
+			for t := range s.tasks {
+				tasks = append(tasks, t)
+			}
+			s.mutex.Unlock()
+
+			for _, t := range tasks {
+				t.task.Flush()
+			}
+
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// 任务有新的事件时调用，放入就绪队列等待执行
+func (t *ScheduledTask) Schedule() {
+	if t.detached.Load() || t.scheduler.closed.Load() {
+		return
+	}
+
+	if t.scheduled.CompareAndSwap(false, true) {
+		t.scheduler.ready.Push(t)
+	}
+}
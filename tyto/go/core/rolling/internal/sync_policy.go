@@ -0,0 +1,11 @@
+package internal
+
+// 同步到磁盘(fsync)的策略
+type SyncPolicy int32
+
+const (
+	SYNC_POLICY_NONE          SyncPolicy = 0 // 只写入操作系统，不主动fsync
+	SYNC_POLICY_INTERVAL      SyncPolicy = 1 // 每次定时刷新缓冲区时fsync
+	SYNC_POLICY_EVERY_WRITE   SyncPolicy = 2 // 每次写入后fsync
+	SYNC_POLICY_EVERY_N_BYTES SyncPolicy = 3 // 每写入N字节后fsync
+)
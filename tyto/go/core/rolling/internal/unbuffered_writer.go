@@ -1,15 +1,20 @@
 package internal
 
-import "os"
+import (
+	"net"
+	"os"
+)
 
 // 无缓冲
 type UnbufferedWriter struct {
-	file *os.File
+	file  *os.File
+	fsync bool // 关闭、重置文件时，是否将数据同步到磁盘
 }
 
-func NewUnbufferedWriter(file *os.File) Writer {
+func NewUnbufferedWriter(file *os.File, fsync bool) Writer {
 	return &UnbufferedWriter{
-		file: file,
+		file:  file,
+		fsync: fsync,
 	}
 }
 
@@ -21,27 +26,33 @@ func (writer *UnbufferedWriter) WriteString(s string) (n int, err error) {
 	return writer.file.WriteString(s)
 }
 
+func (writer *UnbufferedWriter) WriteBuffers(bufs net.Buffers) (int64, error) {
+	return writeBuffers(writer.file, bufs)
+}
+
 func (writer *UnbufferedWriter) IsBuffered() bool {
 	return false
 }
 
+func (writer *UnbufferedWriter) Flush() error {
+	return nil
+}
+
 func (writer *UnbufferedWriter) Sync() error {
 	return writer.file.Sync()
 }
 
 func (writer *UnbufferedWriter) Close() error {
-	writer.file.Sync()
+	if writer.fsync {
+		writer.file.Sync()
+	}
 	return writer.file.Close()
 }
 
 func (writer *UnbufferedWriter) Reset(file *os.File) {
-	writer.file.Sync()
+	if writer.fsync {
+		writer.file.Sync()
+	}
 	writer.file.Close()
 	writer.file = file
 }
-
-// file对象指向的文件是否已经被删除
-func (writer *UnbufferedWriter) IsValid() bool {
-	_, err := writer.file.Stat()
-	return err == nil
-}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"io"
+	"net"
 	"os"
 )
 
@@ -10,12 +11,14 @@ type Writer interface {
 	io.WriteCloser
 	io.StringWriter
 
+	// 一次写入多个缓冲区，无缓冲时使用writev等向量化io，会修改bufs
+	WriteBuffers(bufs net.Buffers) (int64, error)
 	// 是否有缓冲
 	IsBuffered() bool
-	// 将缓冲区的数据写入文件
+	// 将缓冲区的数据写入文件，不保证同步到磁盘
+	Flush() error
+	// 将缓冲区的数据写入文件，并同步到磁盘
 	Sync() error
 	// 重置文件
 	Reset(file *os.File)
-	// 文件是否有效
-	IsValid() bool
 }
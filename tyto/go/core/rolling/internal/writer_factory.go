@@ -2,10 +2,11 @@ package internal
 
 import "os"
 
-func NewWriter(file *os.File, bufferSize int32) Writer {
+// fsync: 关闭、重置文件时，是否将数据同步到磁盘
+func NewWriter(file *os.File, bufferSize int32, fsync bool) Writer {
 	if bufferSize > 0 {
-		return NewBufferedWriter(file, bufferSize)
+		return NewBufferedWriter(file, bufferSize, fsync)
 	}
 
-	return NewUnbufferedWriter(file)
+	return NewUnbufferedWriter(file, fsync)
 }
@@ -0,0 +1,81 @@
+//go:build linux || illumos
+
+package internal
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// 单次writev最多写入的缓冲区数量
+const MAX_IOVEC = 1024
+
+// 使用writev一次写入多个缓冲区，减少系统调用次数
+// 会修改bufs中的元素
+func writeBuffers(file *os.File, bufs [][]byte) (int64, error) {
+	if len(bufs) == 1 {
+		n, err := file.Write(bufs[0])
+		return int64(n), err
+	}
+
+	rawConn, err := file.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	total := int64(0)
+	var writeErr error
+
+	err = rawConn.Write(func(fd uintptr) bool {
+		for len(bufs) > 0 {
+			iovs := bufs
+			if len(iovs) > MAX_IOVEC {
+				iovs = iovs[:MAX_IOVEC]
+			}
+
+			n, err := unix.Writev(int(fd), iovs)
+			if err == unix.EINTR {
+				continue
+			}
+			if err == unix.EAGAIN {
+				// 等待可写后重试
+				return false
+			}
+			if err != nil {
+				writeErr = err
+				return true
+			}
+			if n == 0 {
+				writeErr = io.ErrShortWrite
+				return true
+			}
+
+			total += int64(n)
+			bufs = consumeBuffers(bufs, n)
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return total, err
+	}
+
+	return total, writeErr
+}
+
+// 跳过已写入的n个字节
+func consumeBuffers(bufs [][]byte, n int) [][]byte {
+	for len(bufs) > 0 && n >= len(bufs[0]) {
+		n -= len(bufs[0])
+		bufs = bufs[1:]
+	}
+
+	if n > 0 {
+		bufs[0] = bufs[0][n:]
+	}
+
+	return bufs
+}
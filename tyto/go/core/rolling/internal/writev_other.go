@@ -0,0 +1,19 @@
+//go:build !linux && !illumos
+
+package internal
+
+import "os"
+
+// 没有writev时依次写入
+func writeBuffers(file *os.File, bufs [][]byte) (int64, error) {
+	total := int64(0)
+	for _, buf := range bufs {
+		n, err := file.Write(buf)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
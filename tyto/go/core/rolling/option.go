@@ -38,6 +38,16 @@ func WithLinkName(linkName string) Option {
 	}
 }
 
+// 旋转后，将旧文件移动到归档目录，输出目录中只保留当前文件和符号链接
+// archiveDir为相对于OutDir的目录，规则同NamePattern，如"archive/%Y/%m"会生成"archive/2006/01"
+// 目录中的时间为旧文件所在周期的开始时间，清理时同样会扫描归档目录
+// 启动时，上次运行留下的旧文件同样会被归档，归档目录的时间从文件名中解析
+func WithArchiveDir(archiveDir string) Option {
+	return func(o *internal.Options) {
+		o.ArchiveDir = archiveDir
+	}
+}
+
 // 文件保留时间，<=0表示不清理
 func WithMaxAge(maxAge time.Duration) Option {
 	return func(o *internal.Options) {
@@ -45,6 +55,20 @@ func WithMaxAge(maxAge time.Duration) Option {
 	}
 }
 
+// 只保留最新的maxBackups个旧文件，不含当前文件，<=0表示不限制
+func WithMaxBackups(maxBackups int32) Option {
+	return func(o *internal.Options) {
+		o.MaxBackups = maxBackups
+	}
+}
+
+// 所有文件的总字节数上限，含当前文件，超过后从最旧的文件开始删除，<=0表示不限制
+func WithMaxTotalSize(maxTotalSize int64) Option {
+	return func(o *internal.Options) {
+		o.MaxTotalSize = maxTotalSize
+	}
+}
+
 // 清理过期文件的时间间隔
 func WithCleanupInterval(cleanupInterval time.Duration) Option {
 	return func(o *internal.Options) {
@@ -59,6 +83,22 @@ func WithRotationInterval(rotationInterval time.Duration) Option {
 	}
 }
 
+// 周期开始时间的偏移，如每天05:00旋转时，使用WithRotationInterval(24*time.Hour)和WithRotationOffset(5*time.Hour)
+// 文件名中的时间为周期的开始时间，因此05:00之前的数据写入前一天的文件
+func WithRotationOffset(rotationOffset time.Duration) Option {
+	return func(o *internal.Options) {
+		o.RotationOffset = rotationOffset
+	}
+}
+
+// 划分周期、生成文件名使用的时区，默认为time.Local
+// 夏令时结束时，重复的本地时间生成相同的文件名，两段数据写入同一个文件
+func WithLocation(location *time.Location) Option {
+	return func(o *internal.Options) {
+		o.Location = location
+	}
+}
+
 // 写入队列大小，会自动扩容
 func WithWriterQueueSize(writerQueueSize int32) Option {
 	return func(o *internal.Options) {
@@ -66,6 +106,23 @@ func WithWriterQueueSize(writerQueueSize int32) Option {
 	}
 }
 
+// 写入队列使用无锁队列，大量协程并发写入时，生产者之间不会互相阻塞
+// 此时WriterQueueSize不再生效
+func WithLockFreeQueue(enabled bool) Option {
+	return func(o *internal.Options) {
+		o.LockFreeQueue = enabled
+	}
+}
+
+// 写入队列的最大长度，<=0表示不限制
+// 队列已满时，根据policy阻塞调用者或丢弃数据，丢弃时Write返回ErrDropped
+func WithMaxQueueSize(maxQueueSize int32, policy QueuePolicy) Option {
+	return func(o *internal.Options) {
+		o.MaxQueueSize = maxQueueSize
+		o.QueuePolicy = policy
+	}
+}
+
 // 写入缓冲区大小
 func WithBufferSize(bufferSize int32) Option {
 	return func(o *internal.Options) {
@@ -73,13 +130,142 @@ func WithBufferSize(bufferSize int32) Option {
 	}
 }
 
-// 缓冲区刷到文件的时间间隔
+// 缓冲区刷到文件的时间间隔，SYNC_POLICY_INTERVAL策略下同时也是fsync的间隔，无缓冲时同样生效
 func WithFlushInterval(flushInterval time.Duration) Option {
 	return func(o *internal.Options) {
 		o.FlushInterval = flushInterval
 	}
 }
 
+// 同步到磁盘(fsync)的策略，默认为SYNC_POLICY_INTERVAL
+// syncBytes只在SYNC_POLICY_EVERY_N_BYTES策略下有效
+// 除SYNC_POLICY_NONE外，Sync、Close以及旋转时都会同步到磁盘
+func WithSyncPolicy(policy SyncPolicy, syncBytes int64) Option {
+	return func(o *internal.Options) {
+		o.SyncPolicy = policy
+		o.SyncBytes = syncBytes
+	}
+}
+
+// 单个文件最大字节数，<=0表示不限制
+// 超过后在文件名后追加序号，如"app.txt.2006-01-02.1"
+func WithMaxSize(maxSize int64) Option {
+	return func(o *internal.Options) {
+		o.MaxSize = maxSize
+	}
+}
+
+// 打开文件时的命名方式，默认为FILE_NAMING_APPEND
+// 进程重启、NamePattern的粒度大于RotationInterval时，FILE_NAMING_APPEND会继续写入同名文件，
+// 其余方式每次打开都会创建新文件，以区分不同的运行
+func WithFileNaming(naming FileNaming) Option {
+	return func(o *internal.Options) {
+		o.FileNaming = naming
+	}
+}
+
+// 旋转后，在后台将旧文件压缩，如"app.txt.2006-01-02.gz"
+// 压缩成功后删除原文件，同一时间只有一个文件在压缩
+// level为压缩级别，gzip时取值范围同compress/gzip
+func WithCompression(compression Compression, level int) Option {
+	return func(o *internal.Options) {
+		o.Compression = compression
+		o.CompressionLevel = level
+	}
+}
+
+// 降级模式下，重新打开文件的重试间隔，每次失败后加倍，直到maxInterval
+func WithRetryInterval(minInterval time.Duration, maxInterval time.Duration) Option {
+	return func(o *internal.Options) {
+		o.RetryMinInterval = minInterval
+		o.RetryMaxInterval = maxInterval
+	}
+}
+
+// 降级模式下，数据写入备用目录中的同名文件
+func WithFallbackDir(fallbackDir string) Option {
+	return func(o *internal.Options) {
+		o.FallbackDir = fallbackDir
+	}
+}
+
+// 降级模式下，数据输出到stderr，同时设置了备用目录时，备用目录优先
+func WithFallbackStderr(enabled bool) Option {
+	return func(o *internal.Options) {
+		o.FallbackStderr = enabled
+	}
+}
+
+// 降级模式下，没有可用的备用输出时，数据保存在内存中，恢复后写入文件
+// maxBacklogSize为内存中积压数据的上限，<=0表示不积压
+func WithMaxBacklogSize(maxBacklogSize int64) Option {
+	return func(o *internal.Options) {
+		o.MaxBacklogSize = maxBacklogSize
+	}
+}
+
+// 写入时检查文件是否被移动或删除（比较设备和inode）的时间间隔，<=0表示只在刷新时检查
+// 文件被移动或删除后，会重新打开，兼容logrotate等外部工具
+func WithFileCheckInterval(fileCheckInterval time.Duration) Option {
+	return func(o *internal.Options) {
+		o.FileCheckInterval = fileCheckInterval
+	}
+}
+
+// 收到SIGUSR1信号时，重新打开当前文件，windows下无效
+func WithReopenOnSignal(enabled bool) Option {
+	return func(o *internal.Options) {
+		o.ReopenOnSignal = enabled
+	}
+}
+
+// 新文件的文件头，如csv的表头、审计文件的主机、版本、启动时间等信息
+// 只在创建新文件时写入一次，继续写入已存在的文件时不会写入
+// 在写入协程中调用，不要阻塞
+func WithFileHeader(header FileHeader) Option {
+	return func(o *internal.Options) {
+		o.FileHeader = header
+	}
+}
+
+// 创建时立即打开文件，文件无法打开时NewRotateWriter返回错误
+// 默认等到第一次写入时才打开文件
+func WithEagerOpen(enabled bool) Option {
+	return func(o *internal.Options) {
+		o.EagerOpen = enabled
+	}
+}
+
+// 文件旋转后的回调，在旧文件刷新并关闭之后，于单独的协程中执行，panic会被捕获
+func WithOnRotate(hook RotateHook) Option {
+	return func(o *internal.Options) {
+		o.OnRotate = hook
+	}
+}
+
+// 清理文件后的回调，于单独的协程中执行，panic会被捕获
+func WithOnCleanup(hook CleanupHook) Option {
+	return func(o *internal.Options) {
+		o.OnCleanup = hook
+	}
+}
+
+// 时钟，默认使用系统时钟，测试时可以使用FakeClock手动推进时间
+func WithClock(clock Clock) Option {
+	return func(o *internal.Options) {
+		o.Clock = clock
+	}
+}
+
+// 使用共享的I/O调度器，不再为每个RotateWriter单独创建处理协程和刷新定时器
+// 此时缓冲区的刷新间隔由Scheduler决定，FlushInterval不再生效
+// scheduler需要在所有RotateWriter关闭后再关闭
+func WithScheduler(scheduler *Scheduler) Option {
+	return func(o *internal.Options) {
+		o.Scheduler = scheduler
+	}
+}
+
 // 日志器
 func WithLogger(logger logging.Logger) Option {
 	return func(o *internal.Options) {
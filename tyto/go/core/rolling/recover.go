@@ -0,0 +1,110 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 启动时恢复上次运行时的状态
+// 开启EagerOpen时直接打开文件，否则只恢复当前文件的名称、大小，并更新符号链接，
+// 使大小限制、清理、符号链接在重启后立即生效
+func (w *RotateWriter) recoverState(now time.Time) error {
+	w.recoverClosedFile()
+
+	if w.options.EagerOpen {
+		// 打开的不是上次运行时最后写入的文件时，由openFile处理
+		return w.rotate(0)
+	}
+
+	baseTime := w.getBaseTime(now, w.options.RotationInterval)
+	baseName := internal.GenerateFileName(w.options.NamePattern, baseTime)
+	fileName, index, fileSize := w.resolveFile(now, baseName, w.findStartIndex(baseName))
+	fullPath := filepath.Join(w.options.OutDir, fileName)
+
+	// 上次运行时最后写入的文件不会再继续写入
+	if w.closedFile != "" && w.closedFile != fullPath {
+		w.rotateClosedFile(w.closedFile, w.closedFileTime, fullPath)
+		w.closedFile = ""
+	}
+
+	// 每次打开都创建新文件时，没有可以恢复的当前文件
+	if w.options.FileNaming.IsFresh() {
+		return nil
+	}
+
+	if _, err := os.Stat(fullPath); err != nil {
+		// 当前文件不存在
+		return nil
+	}
+
+	w.baseName = baseName
+	w.fileIndex = index
+	w.fileName = fileName
+	w.fileSize = fileSize
+	w.stats.fileSize.Store(fileSize)
+	w.stats.setFileName(fileName)
+
+	w.updateLink()
+
+	return nil
+}
+
+// 找出上次运行时最后写入的文件，被Close关闭或进程退出时没有经过旋转
+// 当前文件不是该文件时补充旋转处理，继续写入该文件时不处理
+func (w *RotateWriter) recoverClosedFile() {
+	fileList, err := internal.ListFiles(w.options.OutDir, w.globPattern)
+	if err != nil {
+		w.Logger().Error("list file failed:", err.Error())
+		return
+	}
+
+	linkPath := ""
+	if len(w.options.LinkName) != 0 {
+		linkPath = filepath.Join(w.options.OutDir, w.options.LinkName)
+	}
+
+	var lastInfo os.FileInfo
+	lastFile := ""
+	for _, file := range fileList {
+		// 已压缩的文件经过了旋转
+		if file == linkPath || internal.TrimCompressionSuffix(file) != file {
+			continue
+		}
+
+		fileInfo, err := os.Lstat(file)
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			continue
+		}
+
+		if lastInfo == nil || isNewerFile(file, fileInfo, lastFile, lastInfo) {
+			lastFile, lastInfo = file, fileInfo
+		}
+	}
+
+	if lastInfo == nil {
+		return
+	}
+
+	fileTime, ok := internal.ParseFileTime(w.options.NamePattern, filepath.Base(lastFile), w.options.Location)
+	if !ok {
+		fileTime = w.getBaseTime(lastInfo.ModTime(), w.options.RotationInterval)
+	}
+
+	w.closedFile = lastFile
+	w.closedFileTime = fileTime
+}
+
+// 修改时间相同时，序号更大的文件更新
+func isNewerFile(path string, info os.FileInfo, otherPath string, otherInfo os.FileInfo) bool {
+	if !info.ModTime().Equal(otherInfo.ModTime()) {
+		return info.ModTime().After(otherInfo.ModTime())
+	}
+
+	if len(path) != len(otherPath) {
+		return len(path) > len(otherPath)
+	}
+
+	return path > otherPath
+}
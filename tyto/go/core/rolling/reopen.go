@@ -0,0 +1,101 @@
+package rolling
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 关闭并重新打开当前文件，通常在外部工具（如logrotate）移动了文件之后调用
+// 同步阻塞
+func (w *RotateWriter) Reopen() error {
+	if w.closed.Load() {
+		return nil
+	}
+
+	c := make(chan error)
+	event := internal.Event{
+		Type: internal.EVENT_TYPE_REOPEN,
+		Chan: c,
+	}
+
+	// 已关闭
+	if err := w.pushEvent(event); err != nil {
+		return nil
+	}
+
+	// wait
+	err := <-c
+	close(c)
+
+	return err
+}
+
+// 异步重新打开当前文件，用于信号处理
+func (w *RotateWriter) reopenAsync() {
+	if w.closed.Load() {
+		return
+	}
+
+	event := internal.Event{
+		Type: internal.EVENT_TYPE_REOPEN,
+		Chan: nil,
+	}
+
+	_ = w.pushEvent(event)
+}
+
+func (w *RotateWriter) reopen() error {
+	if w.writer == nil {
+		// 下次写入时会自动打开
+		return nil
+	}
+
+	// 关闭前会将缓冲区中的数据写入旧文件
+	err := w.closeWriter()
+	if err != nil {
+		w.Logger().Error("close", w.fileName, "failed when reopen:", err.Error())
+	}
+
+	return w.rotate(0)
+}
+
+// 关闭writer，下次写入时重新打开
+// 下次打开的不是同一个文件时，再对当前文件进行旋转处理
+func (w *RotateWriter) closeWriter() error {
+	err := w.writer.Close()
+	w.writer = nil
+	w.fileInfo = nil
+	w.closedFile = filepath.Join(w.options.OutDir, w.fileName)
+	w.closedFileTime = w.fileTime
+	return err
+}
+
+// 检查当前路径上的文件是否仍是正在写入的文件（比较设备和inode）
+// 文件被移动或删除时，关闭writer，下次写入时重新打开
+// force为false时，每FileCheckInterval最多检查一次
+func (w *RotateWriter) checkFile(now time.Time, force bool) {
+	if w.writer == nil || w.fileInfo == nil {
+		return
+	}
+
+	if !force {
+		if w.options.FileCheckInterval <= 0 || now.Before(w.nextCheckTime) {
+			return
+		}
+		w.nextCheckTime = now.Add(w.options.FileCheckInterval)
+	}
+
+	fullPath := filepath.Join(w.options.OutDir, w.fileName)
+	fileInfo, err := os.Stat(fullPath)
+	if err == nil && os.SameFile(fileInfo, w.fileInfo) {
+		return
+	}
+
+	w.Logger().Warn("file", fullPath, "has been moved or removed, reopen it")
+
+	if err := w.closeWriter(); err != nil {
+		w.Logger().Error("close", fullPath, "failed:", err.Error())
+	}
+}
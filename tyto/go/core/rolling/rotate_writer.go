@@ -2,12 +2,14 @@ package rolling
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
-	"tyto/core/fileutil"
 	"tyto/core/logging"
 	"tyto/core/memutil"
 	"tyto/core/osutil"
@@ -20,18 +22,47 @@ type BufferType = memutil.RefObject[bytes.Buffer]
 // 旋转文件writer
 type RotateWriter struct {
 	options         internal.Options
-	fileName        string
+	baseName        string      // 由NamePattern生成的文件名，不含序号
+	fileIndex       int32       // 当前文件序号，超过MaxSize后递增
+	fileName        string      // 当前文件名，含序号
+	fileSize        int64       // 当前文件大小，包括尚在缓冲区中的数据
+	headerSize      int64       // 当前文件的文件头大小
+	fileTime        time.Time   // 当前文件所在周期的开始时间，用于生成归档目录
+	fileInfo        os.FileInfo // 当前文件打开时的信息，用于判断文件是否被移动
+	closedFile      string      // 没有经过旋转就关闭的文件，打开其他文件时补充旋转处理
+	closedFileTime  time.Time   // closedFile所在周期的开始时间
+	unsyncedBytes   int64       // 上次同步到磁盘后写入的字节数
+	nextCheckTime   time.Time   // 下次检查文件是否被移动的时间
 	globPattern     string
 	writer          internal.Writer
-	queue           *syncutil.DoubleQueue[internal.Event]
+	queue           syncutil.MpscQueue[internal.Event]
+	task            *internal.ScheduledTask // 使用Scheduler时不为nil，不再单独创建处理协程
+	batch           []*BufferType           // 批量写入时复用的切片
+	records         [][]byte                // 批量写入时复用的切片
+	iovecs          [][]byte                // 批量写入时复用的切片
+	slots           chan struct{}           // 写入队列的空位，为nil表示不限制队列长度
+	dropped         atomic.Int64
 	nextRotateTime  time.Time
 	nextCleanupTime time.Time
-	syncTicker      *time.Ticker
+	syncTicker      internal.Ticker
 	syncDone        chan struct{}
+	signalChan      chan os.Signal
+	signalDone      chan struct{}
 	closed          atomic.Bool
+	done            chan struct{} // 队列取空、文件关闭后关闭
+	closeErr        error         // 关闭文件的错误，done关闭后才能读取
 	cleaning        atomic.Bool
+	compressMutex   sync.Mutex
+	compressQueue   []compressTask
+	compressing     bool
+	hookMutex       sync.Mutex
+	hookQueue       []func()
+	hookRunning     bool
 	pool            atomic.Pointer[sync.Pool]
-	lastError       atomic.Value
+	lastError       atomic.Pointer[error]
+	degrade         degradeState
+	isDegraded      atomic.Bool
+	stats           writerStats
 }
 
 func NewRotateWriter(opts ...Option) (*RotateWriter, error) {
@@ -42,6 +73,12 @@ func NewRotateWriter(opts ...Option) (*RotateWriter, error) {
 
 	// 修正目录格式
 	o.OutDir = filepath.Clean(o.OutDir)
+	if o.ArchiveDir != "" {
+		o.ArchiveDir = filepath.Clean(o.ArchiveDir)
+	}
+	if o.FallbackDir != "" {
+		o.FallbackDir = filepath.Clean(o.FallbackDir)
+	}
 
 	// 检查选项合法性
 	if err := o.Validate(); err != nil {
@@ -60,30 +97,89 @@ func NewRotateWriter(opts ...Option) (*RotateWriter, error) {
 		nextCleanupTime time.Time
 	)
 
-	now := time.Now().Local()
-	nextRotateTime = internal.GetBaseTime(now, o.RotationInterval)
-	nextCleanupTime = internal.GetBaseTime(now, o.CleanupInterval)
+	now := o.Clock.Now().In(o.Location)
+	nextRotateTime = internal.GetBaseTime(now, o.RotationInterval, o.RotationOffset, o.Location)
+	nextCleanupTime = internal.GetBaseTime(now, o.CleanupInterval, o.RotationOffset, o.Location)
+
+	var queue syncutil.MpscQueue[internal.Event]
+	if o.LockFreeQueue {
+		queue = syncutil.NewLockFreeQueue[internal.Event]()
+	} else {
+		queue = syncutil.NewDoubleQueue[internal.Event](o.WriterQueueSize)
+	}
 
-	queue := syncutil.NewDoubleQueue[internal.Event](o.WriterQueueSize)
+	var slots chan struct{}
+	if o.MaxQueueSize > 0 {
+		slots = make(chan struct{}, o.MaxQueueSize)
+	}
 
 	writer := &RotateWriter{
 		options:         *o,
+		baseName:        "",
+		fileIndex:       0,
 		fileName:        "",
+		fileSize:        0,
+		headerSize:      0,
+		fileTime:        time.Time{},
+		fileInfo:        nil,
+		unsyncedBytes:   0,
+		nextCheckTime:   time.Time{},
 		globPattern:     globPattern,
 		writer:          nil,
 		queue:           queue,
-		nextRotateTime:  nextCleanupTime,
-		nextCleanupTime: nextRotateTime,
+		task:            nil,
+		batch:           nil,
+		records:         nil,
+		iovecs:          nil,
+		slots:           slots,
+		dropped:         atomic.Int64{},
+		nextRotateTime:  nextRotateTime,
+		nextCleanupTime: nextCleanupTime,
 		syncTicker:      nil,
 		syncDone:        nil,
+		signalChan:      nil,
+		signalDone:      nil,
 		closed:          atomic.Bool{},
+		done:            make(chan struct{}),
+		closeErr:        nil,
 		cleaning:        atomic.Bool{},
+		compressMutex:   sync.Mutex{},
+		compressQueue:   nil,
+		compressing:     false,
+		hookMutex:       sync.Mutex{},
+		hookQueue:       nil,
+		hookRunning:     false,
 		pool:            atomic.Pointer[sync.Pool]{},
-		lastError:       atomic.Value{},
+		lastError:       atomic.Pointer[error]{},
+		degrade:         degradeState{},
+		isDegraded:      atomic.Bool{},
+		stats:           writerStats{},
+	}
+
+	// 恢复上次运行时的状态，或者直接打开文件
+	if err := writer.recoverState(now); err != nil {
+		return nil, err
+	}
+
+	// 归档上次运行时留下的旧文件
+	writer.archiveStaleFiles()
+
+	// 压缩上次运行时没有压缩的旧文件
+	writer.compressStaleFiles()
+
+	// 启动时清理一次过时文件，不必等到下次旋转
+	writer.tryCleanup(now)
+
+	if o.ReopenOnSignal {
+		writer.startSignalHandler()
 	}
 
-	// 启动异步处理协程
-	go writer.run()
+	// 启动异步处理协程，或者交由Scheduler处理
+	if o.Scheduler != nil {
+		writer.task = o.Scheduler.Attach(&writerTask{w: writer})
+	} else {
+		go writer.run()
+	}
 
 	return writer, nil
 }
@@ -146,6 +242,11 @@ func (w *RotateWriter) WriteString(s string) (n int, err error) {
 }
 
 func (w *RotateWriter) writeBuffer(buff *BufferType) (n int, err error) {
+	if !w.acquireSlot() {
+		buff.DecRef()
+		return 0, ErrDropped
+	}
+
 	event := internal.Event{
 		Type:   internal.EVENT_TYPE_BUFFER,
 		Buffer: buff,
@@ -153,15 +254,18 @@ func (w *RotateWriter) writeBuffer(buff *BufferType) (n int, err error) {
 
 	n = buff.Object().Len()
 
-	v := w.lastError.Load()
-	if v != nil {
-		err = v.(error)
-		w.lastError.Store(nil)
+	if p := w.lastError.Swap(nil); p != nil {
+		err = *p
 	}
 
 	// push后，buff的所有权交个另一个go routine
 	// 不要再使用buff
-	w.queue.Push(event)
+	if pushErr := w.pushEvent(event); pushErr != nil {
+		// 已关闭，队列拒绝写入
+		w.releaseSlot()
+		buff.DecRef()
+		return 0, pushErr
+	}
 
 	return
 }
@@ -176,27 +280,22 @@ func (w *RotateWriter) WriteBuffer(buff *BufferType) (n int, err error) {
 	return w.writeBuffer(buff)
 }
 
-func (w *RotateWriter) sync() error {
-	if w.closed.Load() {
-		return nil
-	}
-
+// periodic为true表示由定时器触发
+func (w *RotateWriter) sync(periodic bool) error {
 	// writer为空，无需同步
 	if w.writer == nil {
 		return nil
 	}
 
-	// 通常是操作者手动删除了文件，导致writer失效
+	// 通常是操作者手动删除或移动了文件，导致writer失效
 	// 因此，关闭writer，等待下次写入时重新创建
-	// 这种情况下，会丢失一定时间内的数据
-	// 为了性能，不在每次写入时检查writer是否有效
-	if !w.writer.IsValid() {
-		_ = w.writer.Close()
-		w.writer = nil
+	// 文件被删除时，会丢失一定时间内的数据
+	w.checkFile(w.now(), true)
+	if w.writer == nil {
 		return nil
 	}
 
-	return w.writer.Sync()
+	return w.flush(periodic)
 }
 
 // 同步阻塞
@@ -211,7 +310,10 @@ func (w *RotateWriter) Sync() error {
 		Chan: c,
 	}
 
-	w.queue.Push(event)
+	// 已关闭
+	if err := w.pushEvent(event); err != nil {
+		return nil
+	}
 
 	// wait
 	err := <-c
@@ -220,92 +322,65 @@ func (w *RotateWriter) Sync() error {
 	return err
 }
 
-func (w *RotateWriter) cleanupQueue() {
-	// 确保所有event都被处理
-	for !w.queue.Empty() {
-		e := w.queue.Pop()
-
-		switch e.Type {
-		case internal.EVENT_TYPE_SYNC:
-			if e.Chan == nil {
-				continue
-			}
-			e.Chan <- nil
-
-		case internal.EVENT_TYPE_CLOSE:
-			e.Chan <- nil
+// 队列已关闭且已取空，关闭文件，唤醒等待中的Close
+func (w *RotateWriter) close() {
+	w.closeDegraded()
 
-		case internal.EVENT_TYPE_BUFFER:
-			err := w.handleBuffer(e.Buffer)
-			if err != nil {
-				w.Logger().Error("write buffer failed when cleanup:", err.Error())
-			}
-		}
-	}
-}
-
-func (w *RotateWriter) close() error {
-	if w.closed.Load() {
-		return nil
-	}
-	if !w.closed.CompareAndSwap(false, true) {
-		return nil
-	}
-
-	if w.writer == nil && w.queue.Empty() {
-		return nil
-	}
-
-	// 确保所有event都被处理
-	w.cleanupQueue()
-
-	if w.writer == nil {
-		return nil
+	if w.writer != nil {
+		w.closeErr = w.closeWriter()
 	}
 
-	// 关闭writer
-	err := w.writer.Close()
-	w.writer = nil
-	return err
+	close(w.done)
 }
 
-// 同步阻塞
+// 拒绝之后的写入，已有的事件仍然会被处理，处理完后关闭文件
+// 同步阻塞，重复调用时等待第一次调用完成后返回nil
 func (w *RotateWriter) Close() error {
-	if w.closed.Load() {
+	if !w.closed.CompareAndSwap(false, true) {
+		<-w.done
 		return nil
 	}
 
-	c := make(chan error)
-	event := internal.Event{
-		Type: internal.EVENT_TYPE_CLOSE,
-		Chan: c,
+	// 消费者取空队列后退出
+	w.queue.Close()
+	if w.task != nil {
+		w.task.Schedule()
 	}
 
-	w.queue.Push(event)
-
-	// wait
-	err := <-c
-	close(c)
+	<-w.done
 
-	return err
+	return w.closeErr
 }
 
 func (w *RotateWriter) handleBuffer(buff *BufferType) error {
-	var err error
+	// 释放资源
+	defer buff.DecRef()
 
-	if err = w.rotate(); err != nil {
-		// 通常是权限问题、磁盘空间问题导致文件创建失败
-		return err
+	if w.degrade.degraded {
+		// 未到重试时间，或者重试失败
+		if w.now().Before(w.degrade.nextRetryTime) || !w.tryRecover() {
+			return w.writeDegraded(buff)
+		}
 	}
 
-	_, err = w.writer.Write(buff.Object().Bytes())
-
-	// 释放资源
-	buff.DecRef()
+	written, err := w.writeData(buff.Object().Bytes())
+	if err != nil {
+		w.enterDegraded(err)
+		if !written {
+			_ = w.writeDegraded(buff)
+		}
+	}
 
 	return err
 }
 
+// 写入数据，文件需要旋转时会自动旋转
+// written表示数据已全部或部分写入文件，出错时也不能再重新写入
+func (w *RotateWriter) writeData(data []byte) (written bool, err error) {
+	n, err := w.writeBatch([][]byte{data})
+	return n > 0, err
+}
+
 func (w *RotateWriter) stopTicker() {
 	if w.syncTicker == nil {
 		return
@@ -319,7 +394,7 @@ func (w *RotateWriter) stopTicker() {
 func (w *RotateWriter) handleSyncTicker() {
 	for {
 		select {
-		case <-w.syncTicker.C:
+		case <-w.syncTicker.C():
 			if w.closed.Load() {
 				continue
 			}
@@ -330,7 +405,7 @@ func (w *RotateWriter) handleSyncTicker() {
 				Chan: nil,
 			}
 
-			w.queue.Push(event)
+			_ = w.pushEvent(event)
 
 		case <-w.syncDone:
 			return
@@ -339,156 +414,321 @@ func (w *RotateWriter) handleSyncTicker() {
 }
 
 func (w *RotateWriter) run() {
-	defer w.stopTicker()
-
 	for {
-		e := w.queue.Pop()
-
-		switch e.Type {
-		case internal.EVENT_TYPE_SYNC:
-			err := w.sync()
-			if e.Chan != nil {
-				e.Chan <- err
+		// 队列已关闭且已取空时返回ErrQueueClosed
+		e, err := w.queue.PopContext(context.Background())
+		if err != nil {
+			break
+		}
 
-			} else if err != nil {
-				w.lastError.Store(err)
+		// 连续的写入事件批量处理
+		if e.Type == internal.EVENT_TYPE_BUFFER {
+			var ok bool
+			if e, ok = w.handleBufferEvents(e); !ok {
+				continue
 			}
+		}
 
-		case internal.EVENT_TYPE_CLOSE:
-			err := w.close()
+		w.handleEvent(e)
+	}
+
+	w.stopSignalHandler()
+	w.stopTicker()
+	w.close()
+}
+
+// 处理单个事件
+func (w *RotateWriter) handleEvent(e internal.Event) {
+	switch e.Type {
+	case internal.EVENT_TYPE_SYNC:
+		// 定时器触发的同步事件，没有chan
+		err := w.sync(e.Chan == nil)
+		if e.Chan != nil {
 			e.Chan <- err
-			// 退出
-			return
 
-		case internal.EVENT_TYPE_BUFFER:
-			err := w.handleBuffer(e.Buffer)
-			if err != nil {
-				w.lastError.Store(err)
-			}
+		} else if err != nil {
+			w.setLastError(err)
+		}
+
+	case internal.EVENT_TYPE_REOPEN:
+		err := w.reopen()
+		if e.Chan != nil {
+			e.Chan <- err
+
+		} else if err != nil {
+			w.setLastError(err)
+		}
+
+	case internal.EVENT_TYPE_BUFFER:
+		err := w.handleBuffer(e.Buffer)
+		w.releaseSlot()
+		if err != nil {
+			w.setLastError(err)
 		}
 	}
 }
 
-func (w *RotateWriter) rotate() error {
-	now := time.Now().Local()
+// size为即将写入的数据大小
+func (w *RotateWriter) rotate(size int) error {
+	now := w.now()
 	if now.Before(w.nextRotateTime) && w.writer != nil {
-		// 不需要旋转
-		return nil
+		if !w.isSizeExceeded(size) {
+			// 不需要旋转
+			return nil
+		}
+
+		// 超过大小限制，使用下一个序号
+		return w.openFile(now, w.baseName, w.nextIndex(), w.nextRotateTime)
 	}
 
-	baseTime := internal.GetBaseTime(now, w.options.RotationInterval)
-	fileName := internal.GenerateFileName(w.options.NamePattern, baseTime)
+	baseTime := w.getBaseTime(now, w.options.RotationInterval)
+	baseName := internal.GenerateFileName(w.options.NamePattern, baseTime)
+	nextRotateTime := w.getNextTime(baseTime, w.options.RotationInterval)
 
-	if w.fileName == fileName && w.writer != nil {
+	if w.baseName == baseName && w.writer != nil {
 		// 当前文件名与新文件名相同，不需要旋转
-		w.nextRotateTime = baseTime.Add(w.options.RotationInterval)
+		w.nextRotateTime = nextRotateTime
+		if !w.isSizeExceeded(size) {
+			return nil
+		}
+
+		return w.openFile(now, baseName, w.nextIndex(), nextRotateTime)
+	}
+
+	if err := w.openFile(now, baseName, w.findStartIndex(baseName), nextRotateTime); err != nil {
+		return err
+	}
+
+	// 继续写入已存在的文件时，同样需要检查大小限制
+	if !w.isSizeExceeded(size) {
 		return nil
 	}
 
-	// 创建新文件
+	return w.openFile(now, baseName, w.nextIndex(), nextRotateTime)
+}
+
+// 进程重启等情况下，从已存在的最大序号开始
+func (w *RotateWriter) findStartIndex(baseName string) int32 {
+	if w.options.MaxSize <= 0 && w.options.FileNaming != FILE_NAMING_SEQUENCE {
+		return 0
+	}
+
+	fileList, err := w.listFiles()
+	if err != nil {
+		return 0
+	}
+
+	return internal.FindLastIndex(fileList, baseName)
+}
+
+// 超过大小限制后使用的序号
+// FILE_NAMING_START_TIME时，新文件的打开时间不同，序号从0开始
+func (w *RotateWriter) nextIndex() int32 {
+	if w.options.FileNaming == FILE_NAMING_START_TIME {
+		return 0
+	}
+
+	return w.fileIndex + 1
+}
+
+// 生成文件名，FILE_NAMING_START_TIME时在序号前追加打开时间
+func (w *RotateWriter) makeFileName(now time.Time, baseName string, index int32) string {
+	if w.options.FileNaming == FILE_NAMING_START_TIME {
+		baseName = baseName + "." + now.Format(internal.START_TIME_LAYOUT)
+	}
+
+	return internal.AppendIndex(baseName, index)
+}
+
+// 文件是否已经被压缩过
+func (w *RotateWriter) isCompressed(path string) bool {
+	if w.options.Compression == COMPRESSION_NONE {
+		return false
+	}
+
+	_, err := os.Stat(path + w.options.Compression.Suffix())
+	return err == nil
+}
+
+// 写入size字节后是否超过大小限制
+// 空文件（只有文件头）总是可以写入，避免单条过大的数据导致不停的旋转
+func (w *RotateWriter) isSizeExceeded(size int) bool {
+	if w.options.MaxSize <= 0 || w.fileSize <= w.headerSize {
+		return false
+	}
+
+	return w.fileSize+int64(size) > w.options.MaxSize
+}
+
+// 从index开始查找可以写入的文件，返回文件名、序号和已有的大小
+// 已存在的文件，从文件末尾继续写入
+// FILE_NAMING_APPEND以外的方式，总是创建新文件
+// 已归档的文件名不会再次使用，避免归档时文件名冲突
+func (w *RotateWriter) resolveFile(now time.Time, baseName string, index int32) (string, int32, int64) {
+	fresh := w.options.FileNaming.IsFresh()
+	archived := w.listArchivedNames()
+
+	for {
+		fileName := w.makeFileName(now, baseName, index)
+		fullPath := filepath.Join(w.options.OutDir, fileName)
+
+		_, isArchived := archived[fileName]
+
+		fileInfo, err := os.Stat(fullPath)
+		if err == nil {
+			if !fresh && (w.options.MaxSize <= 0 || fileInfo.Size() < w.options.MaxSize) {
+				return fileName, index, fileInfo.Size()
+			}
+
+		} else if !isArchived && ((!fresh && w.options.MaxSize <= 0) || !w.isCompressed(fullPath)) {
+			return fileName, index, 0
+		}
+
+		// 已写满或已存在，使用下一个序号
+		index++
+	}
+}
+
+// 旧文件已经关闭，归档后压缩、回调
+func (w *RotateWriter) rotateClosedFile(closedPath string, fileTime time.Time, newPath string) {
+	if w.isArchiveEnabled() {
+		closedPath = w.archive(closedPath, fileTime)
+	}
+
+	w.onFileClosed(closedPath, newPath)
+}
+
+// 打开新文件，并替换当前的writer
+func (w *RotateWriter) openFile(now time.Time, baseName string, index int32, nextRotateTime time.Time) error {
+	fileName, index, fileSize := w.resolveFile(now, baseName, index)
 	fullPath := filepath.Join(w.options.OutDir, fileName)
-	file, err := os.OpenFile(fullPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	// 创建新文件，已存在时打开并追加
+	created := true
+	file, err := os.OpenFile(fullPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		created = false
+		file, err = os.OpenFile(fullPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := file.Stat()
 	if err != nil {
+		_ = file.Close()
 		return err
 	}
 
 	// 更新writer
 	if w.writer != nil {
 		w.writer.Reset(file)
+
+		w.stats.rotationCount.Add(1)
+
+		// 旧文件已经关闭，可以压缩、回调
+		w.rotateClosedFile(filepath.Join(w.options.OutDir, w.fileName), w.fileTime, fullPath)
 	} else {
-		w.writer = internal.NewWriter(file, w.options.BufferSize)
+		w.writer = internal.NewWriter(file, w.options.BufferSize, w.isFsyncOnClose())
+
+		// 之前关闭的文件不再写入，被移动或删除时跳过
+		if w.closedFile != "" && w.closedFile != fullPath {
+			if _, err := os.Lstat(w.closedFile); err == nil {
+				w.rotateClosedFile(w.closedFile, w.closedFileTime, fullPath)
+			}
+		}
 	}
+	w.closedFile = ""
 
-	// 开启定时刷新缓存功能
-	if w.writer.IsBuffered() && w.syncTicker == nil {
-		w.syncTicker = time.NewTicker(w.options.FlushInterval)
+	// 开启定时刷新缓存、定时同步功能，使用Scheduler时由其统一刷新
+	if w.isPeriodicFlush() && w.syncTicker == nil && w.options.Scheduler == nil {
+		w.syncTicker = w.options.Clock.NewTicker(w.options.FlushInterval)
 		w.syncDone = make(chan struct{})
 		go w.handleSyncTicker()
 	}
 
+	// 新创建的文件，写入文件头
+	headerSize := int64(0)
+	if created && w.options.FileHeader != nil {
+		headerSize = w.writeHeader(fileName, now)
+		fileSize = headerSize
+	}
+
 	// 更新旋转信息
+	w.baseName = baseName
+	w.fileIndex = index
 	w.fileName = fileName
-	w.nextRotateTime = baseTime.Add(w.options.RotationInterval)
-
-	// 创建软链接
-	if (osutil.IsLinux() || osutil.IsMacOsx()) && len(w.options.LinkName) != 0 {
-		linkPath := filepath.Join(w.options.OutDir, w.options.LinkName)
-		tempPath := linkPath + ".link"
-
-		err = os.Symlink(w.fileName, tempPath)
-		// 1. 失败也没那么重要
-		// 2. 一般不会出错
-		if err != nil {
-			w.Logger().Error("symlink", tempPath, "->", w.fileName, "failed:", err.Error())
-
-		} else {
-			err = os.Rename(tempPath, linkPath)
-			if err != nil {
-				w.Logger().Error("rename", tempPath, "->", linkPath, "failed:", err.Error())
-			}
-		}
-	}
+	w.fileSize = fileSize
+	w.headerSize = headerSize
+	w.fileTime = w.getBaseTime(now, w.options.RotationInterval)
+	w.fileInfo = fileInfo
+	w.unsyncedBytes = 0
+	w.nextCheckTime = now.Add(w.options.FileCheckInterval)
+	w.nextRotateTime = nextRotateTime
+	w.stats.fileSize.Store(fileSize)
+	w.stats.setFileName(fileName)
+
+	w.updateLink()
 
 	// 清理过时文件，每次旋转都会尝试清理一次
-	if w.options.MaxAge > 0 && now.After(w.nextCleanupTime) {
-		baseTime = internal.GetBaseTime(now, w.options.CleanupInterval)
-		w.nextCleanupTime = baseTime.Add(w.options.CleanupInterval)
-		w.cleanup()
-	}
+	w.tryCleanup(now)
 
 	return nil
 }
 
-// 清理过时的文件
-func (w *RotateWriter) cleanup() {
-	// 文件清理过于耗时，清理间隔过短，可能会导致同时启动多个清理协程，因而进行限制
-	if w.cleaning.Load() {
-		return
-	}
-	if !w.cleaning.CompareAndSwap(false, true) {
-		return
+// 写入文件头，返回写入的字节数
+func (w *RotateWriter) writeHeader(fileName string, now time.Time) int64 {
+	header := w.options.FileHeader(fileName, now)
+	if len(header) == 0 {
+		return 0
 	}
 
-	fileList, err := fileutil.ListFile(w.options.OutDir, w.globPattern)
+	n, err := w.writer.Write(header)
 	if err != nil {
-		w.cleaning.Store(false)
-		w.Logger().Error("list file failed:", err.Error())
-		return
+		w.Logger().Error("write header to", fileName, "failed:", err.Error())
 	}
 
-	currentFile := filepath.Join(w.options.OutDir, w.fileName)
-	expiredTime := time.Now().Local().Add(-w.options.MaxAge)
+	w.stats.bytesWritten.Add(int64(n))
 
-	go func() {
-		defer w.cleaning.Store(false)
-
-		for _, file := range fileList {
-			// 跳过当前文件
-			if file == currentFile {
-				continue
-			}
+	return int64(n)
+}
 
-			fileInfo, err := os.Lstat(file)
-			if err != nil {
-				continue
-			}
+// 将符号链接指向当前文件
+func (w *RotateWriter) updateLink() {
+	if !(osutil.IsLinux() || osutil.IsMacOsx()) || len(w.options.LinkName) == 0 {
+		return
+	}
 
-			if !fileInfo.Mode().IsRegular() {
-				// 不是正常的文件
-				continue
-			}
+	linkPath := filepath.Join(w.options.OutDir, w.options.LinkName)
+	tempPath := linkPath + ".link"
 
-			if fileInfo.ModTime().After(expiredTime) {
-				// 未过期
-				continue
-			}
+	err := os.Symlink(w.fileName, tempPath)
+	// 1. 失败也没那么重要
+	// 2. 一般不会出错
+	if err != nil {
+		w.Logger().Error("symlink", tempPath, "->", w.fileName, "failed:", err.Error())
 
-			err = os.Remove(file)
-			if err != nil {
-				w.Logger().Error("remove", file, "failed:", err.Error())
-			}
+	} else {
+		err = os.Rename(tempPath, linkPath)
+		if err != nil {
+			w.Logger().Error("rename", tempPath, "->", linkPath, "failed:", err.Error())
 		}
-	}()
+	}
+}
+
+// 当前时间，时区为Location
+func (w *RotateWriter) now() time.Time {
+	return w.options.Clock.Now().In(w.options.Location)
+}
+
+// 计算t所在周期的开始时间
+func (w *RotateWriter) getBaseTime(t time.Time, interval time.Duration) time.Time {
+	return internal.GetBaseTime(t, interval, w.options.RotationOffset, w.options.Location)
+}
+
+// 计算下一个周期的开始时间
+func (w *RotateWriter) getNextTime(baseTime time.Time, interval time.Duration) time.Time {
+	return internal.GetNextTime(baseTime, interval, w.options.RotationOffset)
 }
 
 func (w *RotateWriter) Logger() logging.Logger {
@@ -0,0 +1,445 @@
+package rolling
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tyto/core/logs/mini"
+)
+
+// 测试使用的起始时间
+var testStartTime = time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)
+
+// 创建使用FakeClock的RotateWriter，默认无缓冲、按天旋转
+func newTestWriter(t testing.TB, dir string, clock *FakeClock, opts ...Option) *RotateWriter {
+	t.Helper()
+
+	defaults := []Option{
+		WithOutDir(dir),
+		WithNamePattern("app.log.%F"),
+		WithLinkName(""),
+		WithLocation(time.UTC),
+		WithBufferSize(0),
+		WithClock(clock),
+		WithLogger(mini.NewLogger()),
+	}
+
+	w, err := NewRotateWriter(append(defaults, opts...)...)
+	if err != nil {
+		t.Fatal("create writer failed:", err)
+	}
+
+	t.Cleanup(func() { _ = w.Close() })
+
+	return w
+}
+
+// 等待cond成立，超时后失败
+func waitFor(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func readFile(t testing.TB, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("read", path, "failed:", err)
+	}
+
+	return string(data)
+}
+
+func mustWrite(t testing.TB, w *RotateWriter, s string) {
+	t.Helper()
+
+	if _, err := w.WriteString(s); err != nil {
+		t.Fatal("write failed:", err)
+	}
+}
+
+func mustSync(t testing.TB, w *RotateWriter) {
+	t.Helper()
+
+	if err := w.Sync(); err != nil {
+		t.Fatal("sync failed:", err)
+	}
+}
+
+func TestRotateAtPeriodBoundary(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime)
+	w := newTestWriter(t, dir, clock)
+
+	mustWrite(t, w, "before\n")
+	mustSync(t, w)
+
+	// 跨过零点，下一次写入旋转到新文件
+	clock.Advance(time.Minute)
+	mustWrite(t, w, "after\n")
+	mustSync(t, w)
+
+	if got := readFile(t, filepath.Join(dir, "app.log.2026-01-01")); got != "before\n" {
+		t.Errorf("old file = %q, want %q", got, "before\n")
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log.2026-01-02")); got != "after\n" {
+		t.Errorf("new file = %q, want %q", got, "after\n")
+	}
+	if got := w.Stats().RotationCount; got != 1 {
+		t.Errorf("RotationCount = %d, want 1", got)
+	}
+
+	// 同一周期内不会旋转
+	clock.Advance(23 * time.Hour)
+	mustWrite(t, w, "same\n")
+	mustSync(t, w)
+
+	if got := readFile(t, filepath.Join(dir, "app.log.2026-01-02")); got != "after\nsame\n" {
+		t.Errorf("new file = %q, want %q", got, "after\nsame\n")
+	}
+}
+
+func TestCleanupMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime)
+
+	// 创建时已过期的文件，以及两天后才过期的文件
+	expired := filepath.Join(dir, "app.log.2025-12-20")
+	recent := filepath.Join(dir, "app.log.2025-12-27")
+	for path, age := range map[string]time.Duration{expired: 10 * 24 * time.Hour, recent: 6 * 24 * time.Hour} {
+		if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := testStartTime.Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := newTestWriter(t, dir, clock,
+		WithMaxAge(7*24*time.Hour),
+		WithCleanupInterval(24*time.Hour),
+	)
+
+	// 启动时清理一次
+	waitFor(t, "expired file removed", func() bool {
+		_, err := os.Stat(expired)
+		return os.IsNotExist(err)
+	})
+	if _, err := os.Stat(recent); err != nil {
+		t.Fatal("recent file should be kept:", err)
+	}
+
+	// 两天后旋转时再次清理
+	clock.Advance(2 * 24 * time.Hour)
+	mustWrite(t, w, "data\n")
+	mustSync(t, w)
+
+	waitFor(t, "recent file removed", func() bool {
+		return w.Stats().RemovedCount == 2
+	})
+	if _, err := os.Stat(recent); !os.IsNotExist(err) {
+		t.Error("recent file should be removed:", err)
+	}
+}
+
+// 同一个清理周期内按大小旋转，每次旋转都检查文件数量
+func TestCleanupMaxBackupsOnRotate(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock,
+		WithMaxSize(20),
+		WithMaxBackups(2),
+		WithCleanupInterval(24*time.Hour),
+	)
+
+	// 每个文件写满后旋转，等待上一次清理结束，避免被跳过
+	for i := 0; i < 5; i++ {
+		mustWrite(t, w, "123456789\n")
+		mustWrite(t, w, "123456789\n")
+		mustSync(t, w)
+		waitFor(t, "cleanup done", func() bool { return !w.cleaning.Load() })
+	}
+
+	mustWrite(t, w, "123456789\n")
+	mustSync(t, w)
+	waitFor(t, "cleanup done", func() bool { return !w.cleaning.Load() })
+
+	checkTree(t, dir, []string{
+		"app.log.2026-01-01.3",
+		"app.log.2026-01-01.4",
+		"app.log.2026-01-01.5",
+	})
+	if n := w.Stats().RemovedCount; n != 3 {
+		t.Errorf("RemovedCount = %d, want 3", n)
+	}
+}
+
+// 没有经过旋转就关闭的文件，打开其他文件时补充回调
+func TestOnRotateAfterClose(t *testing.T) {
+	cases := []struct {
+		name    string
+		restart bool
+		opts    []Option
+	}{
+		{"closeWriter", false, nil},
+		{"restart", true, nil},
+		{"restartEagerOpen", true, []Option{WithEagerOpen(true)}},
+		{"restartSequence", true, []Option{WithFileNaming(FILE_NAMING_SEQUENCE)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := NewFakeClock(testStartTime.Add(-time.Hour))
+
+			var (
+				mu      sync.Mutex
+				rotated []string
+			)
+			opts := append(c.opts, WithOnRotate(func(closedPath string, newPath string) {
+				mu.Lock()
+				defer mu.Unlock()
+				rotated = append(rotated, filepath.Base(closedPath)+" -> "+filepath.Base(newPath))
+			}))
+
+			w := newTestWriter(t, dir, clock, opts...)
+			mustWrite(t, w, "day 1\n")
+			mustSync(t, w)
+
+			if c.restart {
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				// 写入协程阻塞在Pop上，模拟写入出错后关闭文件
+				_ = w.closeWriter()
+			}
+
+			clock.Advance(2 * time.Hour)
+			if c.restart {
+				w = newTestWriter(t, dir, clock, opts...)
+			}
+			mustWrite(t, w, "day 2\n")
+			mustSync(t, w)
+
+			waitFor(t, "rotate hook", func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(rotated) > 0
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if got, want := strings.Join(rotated, ","), "app.log.2026-01-01 -> app.log.2026-01-02"; got != want {
+				t.Errorf("rotated = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// 上次运行时没有压缩的旧文件，启动时压缩，每个文件只回调一次
+func TestCompressStaleFilesOnStartup(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+
+	for i, name := range []string{"app.log.2025-12-30", "app.log.2025-12-31"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := testStartTime.Add(time.Duration(i-3) * 24 * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		rotated []string
+	)
+	w := newTestWriter(t, dir, clock,
+		WithCompression(COMPRESSION_GZIP, gzip.DefaultCompression),
+		WithOnRotate(func(closedPath string, newPath string) {
+			mu.Lock()
+			defer mu.Unlock()
+			rotated = append(rotated, filepath.Base(closedPath))
+		}),
+	)
+
+	waitFor(t, "stale files compressed", func() bool {
+		w.compressMutex.Lock()
+		defer w.compressMutex.Unlock()
+		return !w.compressing
+	})
+	checkTree(t, dir, []string{
+		"app.log.2025-12-30.gz",
+		"app.log.2025-12-31.gz",
+	})
+
+	waitFor(t, "rotate hook", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(rotated) >= 2
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(rotated)
+	if got, want := strings.Join(rotated, ","), "app.log.2025-12-30.gz,app.log.2025-12-31.gz"; got != want {
+		t.Errorf("rotated = %q, want %q", got, want)
+	}
+}
+
+func TestFlushOnTicker(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock,
+		WithBufferSize(4096),
+		WithFlushInterval(time.Second),
+	)
+
+	mustWrite(t, w, "buffered\n")
+	waitFor(t, "record written", func() bool {
+		return w.Stats().RecordsWritten == 1
+	})
+
+	path := filepath.Join(dir, "app.log.2026-01-01")
+	if got := readFile(t, path); got != "" {
+		t.Fatalf("file = %q before flush, want empty", got)
+	}
+
+	// 定时器触发后刷新缓冲区
+	clock.Advance(time.Second)
+	waitFor(t, "buffer flushed", func() bool {
+		return readFile(t, path) == "buffered\n"
+	})
+}
+
+func TestCloseDrainsQueue(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock,
+		WithBufferSize(4096),
+		WithFlushInterval(time.Hour),
+	)
+
+	const count = 10000
+
+	var expected bytes.Buffer
+	for i := 0; i < count; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		expected.WriteString(line)
+		mustWrite(t, w, line)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal("close failed:", err)
+	}
+
+	if got := readFile(t, filepath.Join(dir, "app.log.2026-01-01")); got != expected.String() {
+		t.Fatalf("file has %d bytes, want %d", len(got), expected.Len())
+	}
+
+	if _, err := w.WriteString("closed\n"); err != os.ErrClosed {
+		t.Errorf("write after close = %v, want os.ErrClosed", err)
+	}
+}
+
+// 关闭时仍有写入、同步和关闭的调用，成功写入的数据不能丢失，调用都能返回
+func TestCloseWithConcurrentCallers(t *testing.T) {
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	scheduler := NewScheduler(2, time.Second, clock)
+	defer scheduler.Close()
+
+	cases := []struct {
+		name string
+		opt  Option
+	}{
+		{"DoubleQueue", WithLockFreeQueue(false)},
+		{"LockFreeQueue", WithLockFreeQueue(true)},
+		{"Scheduler", WithScheduler(scheduler)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w := newTestWriter(t, dir, clock, c.opt)
+
+			const writers = 8
+
+			var (
+				wg      sync.WaitGroup
+				written [writers]int
+			)
+			for g := 0; g < writers; g++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						if _, err := w.WriteString(fmt.Sprintf("%d-%d\n", g, i)); err != nil {
+							return
+						}
+						written[g]++
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for !w.closed.Load() {
+						_ = w.Sync()
+					}
+					_ = w.Close()
+				}()
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			if err := w.Close(); err != nil {
+				t.Fatal("close failed:", err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("callers blocked after close")
+			}
+
+			// 没有成功的写入时，不会创建文件
+			data, err := os.ReadFile(filepath.Join(dir, "app.log.2026-01-01"))
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			lines := make(map[string]struct{})
+			for _, line := range strings.Split(string(data), "\n") {
+				lines[line] = struct{}{}
+			}
+			for g := 0; g < writers; g++ {
+				for i := 0; i < written[g]; i++ {
+					if _, ok := lines[fmt.Sprintf("%d-%d", g, i)]; !ok {
+						t.Fatalf("line %d-%d is lost", g, i)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package rolling
+
+import (
+	"os"
+	"time"
+	"tyto/core/rolling/internal"
+	"tyto/core/syncutil"
+)
+
+// 共享的I/O调度器，多个RotateWriter使用固定数量的协程写入，并共享一个刷新定时器
+// 同一个RotateWriter的写入顺序保持不变
+type Scheduler = internal.Scheduler
+
+// workers为工作协程数量，flushInterval为所有RotateWriter共同的缓冲区刷新间隔
+// clock为nil时使用系统时钟
+func NewScheduler(workers int32, flushInterval time.Duration, clock Clock) *Scheduler {
+	if clock == nil {
+		clock = internal.NewSystemClock()
+	}
+
+	return internal.NewScheduler(workers, flushInterval, clock)
+}
+
+// 适配Scheduler的任务
+type writerTask struct {
+	w *RotateWriter
+}
+
+func (t *writerTask) Run(batchSize int) bool {
+	w := t.w
+
+	for i := 0; i < batchSize; i++ {
+		e, err := w.queue.TryPop()
+		if err == syncutil.ErrQueueClosed {
+			// 队列已关闭且已取空
+			w.stopSignalHandler()
+			w.close()
+			return false
+		}
+		if err != nil {
+			break
+		}
+
+		// 连续的写入事件批量处理
+		if e.Type == internal.EVENT_TYPE_BUFFER {
+			var ok bool
+			if e, ok = w.handleBufferEvents(e); !ok {
+				continue
+			}
+		}
+
+		w.handleEvent(e)
+	}
+
+	return true
+}
+
+// 队列长度由原子计数器维护，可以与其他工作协程的Run并发调用
+// 队列关闭后，直到Run取空队列并返回false之前，都需要继续调度
+func (t *writerTask) Pending() bool {
+	return !t.w.queue.Empty() || t.w.queue.IsClosed()
+}
+
+func (t *writerTask) Flush() {
+	w := t.w
+	if w.closed.Load() || !w.isPeriodicFlush() {
+		return
+	}
+
+	// 不需要chan获取结果
+	event := internal.Event{
+		Type: internal.EVENT_TYPE_SYNC,
+		Chan: nil,
+	}
+
+	_ = w.pushEvent(event)
+}
+
+// 放入事件队列，使用Scheduler时通知其调度
+// 已关闭时返回os.ErrClosed
+func (w *RotateWriter) pushEvent(e internal.Event) error {
+	if err := w.queue.Push(e); err != nil {
+		return os.ErrClosed
+	}
+
+	if w.task != nil {
+		w.task.Schedule()
+	}
+
+	return nil
+}
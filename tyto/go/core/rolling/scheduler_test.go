@@ -0,0 +1,59 @@
+package rolling
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerMultipleWriters(t *testing.T) {
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	scheduler := NewScheduler(4, time.Second, clock)
+
+	const (
+		writers = 8
+		count   = 2000
+	)
+
+	dirs := make([]string, writers)
+	ws := make([]*RotateWriter, writers)
+	for i := range ws {
+		dirs[i] = t.TempDir()
+		ws[i] = newTestWriter(t, dirs[i], clock, WithScheduler(scheduler))
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range ws {
+		wg.Add(1)
+		go func(w *RotateWriter) {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				mustWrite(t, w, fmt.Sprintf("%d\n", j))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, w := range ws {
+		if err := w.Close(); err != nil {
+			t.Fatal("close failed:", err)
+		}
+	}
+	scheduler.Close()
+
+	// 每个writer的写入顺序保持不变
+	for _, dir := range dirs {
+		lines := strings.Split(strings.TrimSuffix(readFile(t, filepath.Join(dir, "app.log.2026-01-01")), "\n"), "\n")
+		if len(lines) != count {
+			t.Fatalf("%s has %d lines, want %d", dir, len(lines), count)
+		}
+		for j, line := range lines {
+			if line != fmt.Sprint(j) {
+				t.Fatalf("%s line %d = %q", dir, j, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package rolling
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// 收到SIGUSR1信号时，重新打开当前文件
+func (w *RotateWriter) startSignalHandler() {
+	w.signalChan = make(chan os.Signal, 1)
+	w.signalDone = make(chan struct{})
+
+	signal.Notify(w.signalChan, syscall.SIGUSR1)
+
+	go func() {
+		for {
+			select {
+			case <-w.signalChan:
+				w.reopenAsync()
+
+			case <-w.signalDone:
+				return
+			}
+		}
+	}()
+}
+
+func (w *RotateWriter) stopSignalHandler() {
+	if w.signalChan == nil {
+		return
+	}
+
+	signal.Stop(w.signalChan)
+	close(w.signalDone)
+}
@@ -0,0 +1,9 @@
+package rolling
+
+// windows下没有SIGUSR1信号
+func (w *RotateWriter) startSignalHandler() {
+	w.Logger().Error("reopen on signal failed, system unsupported")
+}
+
+func (w *RotateWriter) stopSignalHandler() {
+}
@@ -0,0 +1,98 @@
+package rolling
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 运行时统计信息
+type Stats struct {
+	BytesWritten     int64         // 写入文件的总字节数
+	RecordsWritten   int64         // 写入文件的总次数
+	FileName         string        // 当前文件名
+	FileSize         int64         // 当前文件大小，包括尚在缓冲区中的数据
+	QueueLen         int32         // 写入队列中等待处理的事件数量
+	DroppedCount     int64         // 因队列已满而丢弃的写入次数
+	Degraded         bool          // 是否处于降级模式
+	LastError        error         // 最近一次发生的错误
+	LastErrorTime    time.Time     // 最近一次发生错误的时间
+	RotationCount    int64         // 文件旋转次数
+	CleanupCount     int64         // 清理执行次数
+	RemovedCount     int64         // 清理删除的文件数量
+	LastFlushLatency time.Duration // 最近一次刷新到磁盘的耗时
+	MaxFlushLatency  time.Duration // 刷新到磁盘的最大耗时
+}
+
+// 统计数据，由写入协程更新，可在任意协程读取
+type writerStats struct {
+	bytesWritten     atomic.Int64
+	recordsWritten   atomic.Int64
+	fileSize         atomic.Int64
+	rotationCount    atomic.Int64
+	cleanupCount     atomic.Int64
+	removedCount     atomic.Int64
+	lastFlushLatency atomic.Int64
+	maxFlushLatency  atomic.Int64
+	mutex            sync.Mutex
+	fileName         string
+	lastError        error
+	lastErrorTime    time.Time
+}
+
+func (s *writerStats) setFileName(fileName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fileName = fileName
+}
+
+func (s *writerStats) setError(err error, now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastError = err
+	s.lastErrorTime = now
+}
+
+func (s *writerStats) addFlushLatency(latency time.Duration) {
+	s.lastFlushLatency.Store(int64(latency))
+
+	for {
+		max := s.maxFlushLatency.Load()
+		if int64(latency) <= max || s.maxFlushLatency.CompareAndSwap(max, int64(latency)) {
+			return
+		}
+	}
+}
+
+// 获取运行时统计信息，可用于监控写入是否落后，可在任意协程调用
+func (w *RotateWriter) Stats() Stats {
+	stats := Stats{
+		BytesWritten:     w.stats.bytesWritten.Load(),
+		RecordsWritten:   w.stats.recordsWritten.Load(),
+		FileSize:         w.stats.fileSize.Load(),
+		QueueLen:         w.QueueLen(),
+		DroppedCount:     w.DroppedCount(),
+		Degraded:         w.IsDegraded(),
+		RotationCount:    w.stats.rotationCount.Load(),
+		CleanupCount:     w.stats.cleanupCount.Load(),
+		RemovedCount:     w.stats.removedCount.Load(),
+		LastFlushLatency: time.Duration(w.stats.lastFlushLatency.Load()),
+		MaxFlushLatency:  time.Duration(w.stats.maxFlushLatency.Load()),
+	}
+
+	w.stats.mutex.Lock()
+	stats.FileName = w.stats.fileName
+	stats.LastError = w.stats.lastError
+	stats.LastErrorTime = w.stats.lastErrorTime
+	w.stats.mutex.Unlock()
+
+	return stats
+}
+
+// 记录错误，下次调用Write时返回，同时保存到统计信息中
+func (w *RotateWriter) setLastError(err error) {
+	w.lastError.Store(&err)
+	w.stats.setError(err, w.now())
+}
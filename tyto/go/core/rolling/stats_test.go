@@ -0,0 +1,69 @@
+package rolling
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime)
+	w := newTestWriter(t, dir, clock, WithMaxSize(64))
+
+	done := make(chan struct{})
+	var monitor sync.WaitGroup
+	monitor.Add(1)
+	go func() {
+		defer monitor.Done()
+
+		// 与写入、旋转并发读取统计信息
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				stats := w.Stats()
+				if stats.QueueLen < 0 || stats.FileSize < 0 {
+					t.Errorf("invalid stats: %+v", stats)
+				}
+			}
+		}
+	}()
+
+	line := strings.Repeat("x", 15) + "\n"
+	for i := 0; i < 100; i++ {
+		mustWrite(t, w, line)
+	}
+	mustSync(t, w)
+
+	// 跨过零点
+	clock.Advance(time.Minute)
+	mustWrite(t, w, line)
+	mustSync(t, w)
+
+	close(done)
+	monitor.Wait()
+
+	stats := w.Stats()
+	if stats.RecordsWritten != 101 {
+		t.Errorf("RecordsWritten = %d, want 101", stats.RecordsWritten)
+	}
+	if stats.BytesWritten != int64(101*len(line)) {
+		t.Errorf("BytesWritten = %d, want %d", stats.BytesWritten, 101*len(line))
+	}
+	if stats.FileName != "app.log.2026-01-02" {
+		t.Errorf("FileName = %q, want %q", stats.FileName, "app.log.2026-01-02")
+	}
+	if stats.FileSize != int64(len(line)) {
+		t.Errorf("FileSize = %d, want %d", stats.FileSize, len(line))
+	}
+	// 每个文件4行，100行需要旋转24次，跨天再旋转1次
+	if stats.RotationCount != 25 {
+		t.Errorf("RotationCount = %d, want 25", stats.RotationCount)
+	}
+	if stats.QueueLen != 0 {
+		t.Errorf("QueueLen = %d, want 0", stats.QueueLen)
+	}
+}
@@ -0,0 +1,72 @@
+package rolling
+
+import (
+	"time"
+	"tyto/core/rolling/internal"
+)
+
+// 同步到磁盘(fsync)的策略
+type SyncPolicy = internal.SyncPolicy
+
+const (
+	SYNC_POLICY_NONE          = internal.SYNC_POLICY_NONE          // 只写入操作系统，不主动fsync
+	SYNC_POLICY_INTERVAL      = internal.SYNC_POLICY_INTERVAL      // 每次定时刷新缓冲区时fsync
+	SYNC_POLICY_EVERY_WRITE   = internal.SYNC_POLICY_EVERY_WRITE   // 每次写入后fsync
+	SYNC_POLICY_EVERY_N_BYTES = internal.SYNC_POLICY_EVERY_N_BYTES // 每写入N字节后fsync
+)
+
+// 关闭、重置文件时，是否同步到磁盘
+func (w *RotateWriter) isFsyncOnClose() bool {
+	return w.options.SyncPolicy != SYNC_POLICY_NONE
+}
+
+// 是否需要定时刷新：有缓冲区，或者需要定时同步到磁盘
+func (w *RotateWriter) isPeriodicFlush() bool {
+	return w.options.BufferSize > 0 || w.options.SyncPolicy == SYNC_POLICY_INTERVAL
+}
+
+// 按照同步策略刷新缓冲区
+// periodic为true表示由定时器触发，否则为调用者主动要求同步
+func (w *RotateWriter) flush(periodic bool) error {
+	fsync := false
+
+	switch w.options.SyncPolicy {
+	case SYNC_POLICY_NONE:
+		fsync = false
+	case SYNC_POLICY_INTERVAL:
+		fsync = true
+	default:
+		// 由写入触发fsync，定时器只刷新缓冲区
+		fsync = !periodic
+	}
+
+	begin := time.Now()
+
+	var err error
+	if fsync {
+		err = w.writer.Sync()
+		w.unsyncedBytes = 0
+	} else {
+		err = w.writer.Flush()
+	}
+
+	w.stats.addFlushLatency(time.Since(begin))
+
+	return err
+}
+
+// 写入n字节后，按照同步策略同步到磁盘
+func (w *RotateWriter) syncAfterWrite(n int) error {
+	switch w.options.SyncPolicy {
+	case SYNC_POLICY_EVERY_WRITE:
+		return w.flush(false)
+
+	case SYNC_POLICY_EVERY_N_BYTES:
+		w.unsyncedBytes += int64(n)
+		if w.unsyncedBytes >= w.options.SyncBytes {
+			return w.flush(false)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package rolling
+
+import (
+	"testing"
+	"time"
+	"tyto/core/logs/mini"
+)
+
+func TestUnbufferedIntervalSync(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock,
+		WithFlushInterval(time.Second),
+		WithSyncPolicy(SYNC_POLICY_INTERVAL, 0),
+	)
+
+	mustWrite(t, w, "data\n")
+	waitFor(t, "record written", func() bool {
+		return w.Stats().RecordsWritten == 1
+	})
+	if latency := w.Stats().LastFlushLatency; latency != 0 {
+		t.Fatalf("LastFlushLatency = %v before tick, want 0", latency)
+	}
+
+	// 无缓冲时，定时器同样会触发fsync
+	clock.Advance(time.Second)
+	waitFor(t, "periodic fsync", func() bool {
+		return w.Stats().LastFlushLatency > 0
+	})
+}
+
+func TestUnbufferedNoneWithoutTicker(t *testing.T) {
+	dir := t.TempDir()
+	clock := NewFakeClock(testStartTime.Add(-time.Hour))
+	w := newTestWriter(t, dir, clock,
+		WithFlushInterval(0),
+		WithSyncPolicy(SYNC_POLICY_NONE, 0),
+	)
+
+	mustWrite(t, w, "data\n")
+	mustSync(t, w)
+
+	// Sync之后读取，与写入协程之间有先后关系
+	if w.syncTicker != nil {
+		t.Error("unbuffered writer without SYNC_POLICY_INTERVAL should not start a ticker")
+	}
+}
+
+func TestIntervalRequiresFlushInterval(t *testing.T) {
+	_, err := NewRotateWriter(
+		WithOutDir(t.TempDir()),
+		WithNamePattern("app.log.%F"),
+		WithBufferSize(0),
+		WithFlushInterval(0),
+		WithSyncPolicy(SYNC_POLICY_INTERVAL, 0),
+		WithLogger(mini.NewLogger()),
+	)
+	if err == nil {
+		t.Fatal("SYNC_POLICY_INTERVAL without FlushInterval should be rejected")
+	}
+}
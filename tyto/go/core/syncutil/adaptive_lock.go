@@ -0,0 +1,71 @@
+package syncutil
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// 自适应锁休眠前的自旋次数
+const ADAPTIVE_SPIN_COUNT = 16
+
+// 自适应锁的状态
+const (
+	ADAPTIVE_UNLOCKED  = 0
+	ADAPTIVE_LOCKED    = 1
+	ADAPTIVE_CONTENDED = 2 // 已加锁，且可能有协程在休眠等待
+)
+
+var _ sync.Locker = (*AdaptiveLock)(nil)
+
+// 自适应锁，零值可用，不可重入，不可复制
+// 先短暂自旋，仍未获得锁时休眠等待，由解锁者唤醒
+// 临界区长短不一时，既避免了SpinLock长时间空转，也避免了短临界区下频繁休眠
+type AdaptiveLock struct {
+	state atomic.Int32
+	mutex sync.Mutex // 保护休眠与唤醒
+	cond  sync.Cond
+}
+
+// 加锁
+func (lock *AdaptiveLock) Lock() {
+	for i := 0; i < ADAPTIVE_SPIN_COUNT; i++ {
+		if lock.state.Load() == ADAPTIVE_UNLOCKED && lock.state.CompareAndSwap(ADAPTIVE_UNLOCKED, ADAPTIVE_LOCKED) {
+			return
+		}
+		runtime.Gosched()
+	}
+
+	lock.mutex.Lock()
+	if lock.cond.L == nil {
+		lock.cond.L = &lock.mutex
+	}
+
+	// 标记为有等待者，解锁者据此唤醒；被唤醒时保持该标记，因为可能还有其他等待者
+	for lock.state.Swap(ADAPTIVE_CONTENDED) != ADAPTIVE_UNLOCKED {
+		lock.cond.Wait()
+	}
+	lock.mutex.Unlock()
+}
+
+// 尝试加锁，成功返回true，不会阻塞
+func (lock *AdaptiveLock) TryLock() bool {
+	return lock.state.CompareAndSwap(ADAPTIVE_UNLOCKED, ADAPTIVE_LOCKED)
+}
+
+// 解锁，未加锁时panic
+func (lock *AdaptiveLock) Unlock() {
+	switch lock.state.Swap(ADAPTIVE_UNLOCKED) {
+	case ADAPTIVE_LOCKED:
+		return
+	case ADAPTIVE_CONTENDED:
+		// 等待者在休眠前一直持有mutex，加锁后再唤醒，不会丢失
+		lock.mutex.Lock()
+		if lock.cond.L != nil {
+			lock.cond.Signal()
+		}
+		lock.mutex.Unlock()
+	default:
+		panic("syncutil: unlock of unlocked AdaptiveLock")
+	}
+}
@@ -0,0 +1,128 @@
+package syncutil
+
+import (
+	"context"
+	"time"
+)
+
+// 有界双缓冲队列，适合多生产者单消费者场景，不要使用多个线程去调用Pop系列的方法
+// 队列已满时，Push阻塞等待空位
+type BoundedDoubleQueue[T any] struct {
+	queue *DoubleQueue[T]
+	slots boundedSlots
+}
+
+func NewBoundedDoubleQueue[T any](capacity int32) *BoundedDoubleQueue[T] {
+	return &BoundedDoubleQueue[T]{
+		queue: NewDoubleQueue[T](capacity),
+		slots: newBoundedSlots(capacity),
+	}
+}
+
+// 添加元素到队列尾部，队列已满时阻塞，已关闭时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) Push(v T) error {
+	if err := q.slots.acquire(); err != nil {
+		return err
+	}
+
+	return q.push(v)
+}
+
+// 添加元素到队列尾部，队列已满时返回ErrQueueFull，已关闭时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) TryPush(v T) error {
+	if err := q.slots.tryAcquire(); err != nil {
+		return err
+	}
+
+	return q.push(v)
+}
+
+// 添加元素到队列尾部，最多等待d，超时返回ErrQueueTimeout，已关闭时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) PushTimeout(v T, d time.Duration) error {
+	if err := q.slots.acquireTimeout(d); err != nil {
+		return err
+	}
+
+	return q.push(v)
+}
+
+func (q *BoundedDoubleQueue[T]) push(v T) error {
+	if err := q.queue.Push(v); err != nil {
+		q.slots.release()
+		return err
+	}
+
+	return nil
+}
+
+// 弹出队列头部元素，队列为空时阻塞
+// 已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) Pop() (T, error) {
+	return q.pop(q.queue.pop(nil, nil))
+}
+
+// 弹出队列头部元素，不阻塞
+// 队列为空时返回ErrQueueEmpty，已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) TryPop() (T, error) {
+	return q.pop(q.queue.TryPop())
+}
+
+// 弹出队列头部元素，最多等待d
+// 超时返回ErrQueueTimeout，已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) PopTimeout(d time.Duration) (T, error) {
+	return q.pop(q.queue.PopTimeout(d))
+}
+
+// 弹出队列头部元素，等待直到ctx结束
+// ctx结束时返回ctx.Err()，已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) PopContext(ctx context.Context) (T, error) {
+	return q.pop(q.queue.PopContext(ctx))
+}
+
+// 弹出队列中的所有元素，追加到dst后返回，队列为空时阻塞
+// 已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedDoubleQueue[T]) PopAll(dst []T) ([]T, error) {
+	n := len(dst)
+	dst, err := q.queue.PopAll(dst)
+
+	for i := n; i < len(dst); i++ {
+		q.slots.release()
+	}
+
+	return dst, err
+}
+
+// 取出元素后释放空位
+func (q *BoundedDoubleQueue[T]) pop(v T, err error) (T, error) {
+	if err == nil {
+		q.slots.release()
+	}
+
+	return v, err
+}
+
+// 关闭队列，之后的Push会失败，已有的元素仍然可以取出
+// 会唤醒所有等待中的生产者和消费者
+func (q *BoundedDoubleQueue[T]) Close() {
+	q.queue.Close()
+	q.slots.close()
+}
+
+// 是否已关闭
+func (q *BoundedDoubleQueue[T]) IsClosed() bool {
+	return q.queue.IsClosed()
+}
+
+// 队列长度
+func (q *BoundedDoubleQueue[T]) Len() int32 {
+	return q.queue.Len()
+}
+
+// 队列容量
+func (q *BoundedDoubleQueue[T]) Cap() int32 {
+	return q.slots.capacity()
+}
+
+func (q *BoundedDoubleQueue[T]) Empty() bool {
+	return q.queue.Empty()
+}
@@ -0,0 +1,200 @@
+package syncutil
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BoundedDoubleQueue和BoundedWaitQueue共同的方法
+type boundedQueue interface {
+	Push(v int) error
+	TryPush(v int) error
+	PushTimeout(v int, d time.Duration) error
+	Pop() (int, error)
+	TryPop() (int, error)
+	Close()
+	Len() int32
+	Cap() int32
+}
+
+func forEachBoundedQueue(t *testing.T, capacity int32, f func(t *testing.T, q boundedQueue)) {
+	t.Run("BoundedDoubleQueue", func(t *testing.T) { f(t, NewBoundedDoubleQueue[int](capacity)) })
+	t.Run("BoundedWaitQueue", func(t *testing.T) { f(t, NewBoundedWaitQueue[int](capacity)) })
+}
+
+func TestBoundedQueuePushBlocks(t *testing.T) {
+	forEachBoundedQueue(t, 2, func(t *testing.T, q boundedQueue) {
+		for i := 0; i < 2; i++ {
+			if err := q.Push(i); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pushed := make(chan error, 1)
+		go func() { pushed <- q.Push(2) }()
+
+		select {
+		case err := <-pushed:
+			t.Fatal("Push should block when the queue is full, got", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		// 取出一个元素后，阻塞的Push完成
+		if v, err := q.TryPop(); err != nil || v != 0 {
+			t.Fatalf("TryPop = %d, %v, want 0", v, err)
+		}
+		if err := <-pushed; err != nil {
+			t.Fatal("Push failed:", err)
+		}
+		if n := q.Len(); n != 2 {
+			t.Errorf("Len = %d, want 2", n)
+		}
+	})
+}
+
+func TestBoundedQueueTryPush(t *testing.T) {
+	forEachBoundedQueue(t, 1, func(t *testing.T, q boundedQueue) {
+		if err := q.TryPush(1); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.TryPush(2); err != ErrQueueFull {
+			t.Fatalf("TryPush = %v, want ErrQueueFull", err)
+		}
+
+		if _, err := q.TryPop(); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.TryPush(3); err != nil {
+			t.Fatal("TryPush after pop failed:", err)
+		}
+		if n := q.Cap(); n != 1 {
+			t.Errorf("Cap = %d, want 1", n)
+		}
+	})
+}
+
+func TestBoundedQueuePushTimeout(t *testing.T) {
+	forEachBoundedQueue(t, 1, func(t *testing.T, q boundedQueue) {
+		if err := q.PushTimeout(1, time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		begin := time.Now()
+		if err := q.PushTimeout(2, 20*time.Millisecond); err != ErrQueueTimeout {
+			t.Fatalf("PushTimeout = %v, want ErrQueueTimeout", err)
+		}
+		if elapsed := time.Since(begin); elapsed < 20*time.Millisecond {
+			t.Errorf("PushTimeout returned after %v", elapsed)
+		}
+
+		if err := q.PushTimeout(3, 0); err != ErrQueueTimeout {
+			t.Fatalf("PushTimeout(0) = %v, want ErrQueueTimeout", err)
+		}
+	})
+}
+
+func TestBoundedQueueCloseWakesProducers(t *testing.T) {
+	forEachBoundedQueue(t, 1, func(t *testing.T, q boundedQueue) {
+		if err := q.Push(1); err != nil {
+			t.Fatal(err)
+		}
+
+		const producers = 4
+
+		errs := make(chan error, producers)
+		for i := 0; i < producers; i++ {
+			go func() { errs <- q.Push(2) }()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		q.Close()
+
+		for i := 0; i < producers; i++ {
+			select {
+			case err := <-errs:
+				if err != ErrQueueClosed {
+					t.Errorf("Push = %v, want ErrQueueClosed", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Close did not wake blocked producers")
+			}
+		}
+
+		// 已有的元素仍然可以取出，取空后Pop不再阻塞
+		if v, err := q.Pop(); err != nil || v != 1 {
+			t.Fatalf("Pop = %d, %v, want 1", v, err)
+		}
+		if _, err := q.Pop(); err != ErrQueueClosed {
+			t.Fatalf("Pop = %v, want ErrQueueClosed", err)
+		}
+		if _, err := q.TryPop(); err != ErrQueueClosed {
+			t.Fatalf("TryPop = %v, want ErrQueueClosed", err)
+		}
+		if err := q.TryPush(3); err != ErrQueueClosed {
+			t.Fatalf("TryPush = %v, want ErrQueueClosed", err)
+		}
+	})
+}
+
+// producers个生产者共写入b.N个元素，一个消费者取出所有元素，pop返回取出的元素数量
+func benchmarkProducerConsumer(b *testing.B, producers int, push func(v int), pop func() int) {
+	done := make(chan struct{})
+	go func() {
+		for n := 0; n < b.N; {
+			n += pop()
+		}
+		close(done)
+	}()
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		count := b.N / producers
+		if p < b.N%producers {
+			count++
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				push(i)
+			}
+		}()
+	}
+
+	wg.Wait()
+	<-done
+}
+
+func BenchmarkBoundedQueue(b *testing.B) {
+	const capacity = 1024
+
+	for _, producers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("chan/producers=%d", producers), func(b *testing.B) {
+			c := make(chan int, capacity)
+			benchmarkProducerConsumer(b, producers, func(v int) { c <- v }, func() int { <-c; return 1 })
+		})
+
+		b.Run(fmt.Sprintf("BoundedDoubleQueue/producers=%d", producers), func(b *testing.B) {
+			q := NewBoundedDoubleQueue[int](capacity)
+			benchmarkProducerConsumer(b, producers, func(v int) { _ = q.Push(v) }, func() int { _, _ = q.Pop(); return 1 })
+		})
+
+		// 消费者一次取出所有元素
+		b.Run(fmt.Sprintf("BoundedDoubleQueue.PopAll/producers=%d", producers), func(b *testing.B) {
+			q := NewBoundedDoubleQueue[int](capacity)
+			vs := make([]int, 0, capacity)
+			benchmarkProducerConsumer(b, producers, func(v int) { _ = q.Push(v) }, func() int {
+				vs, _ = q.PopAll(vs[:0])
+				return len(vs)
+			})
+		})
+
+		b.Run(fmt.Sprintf("BoundedWaitQueue/producers=%d", producers), func(b *testing.B) {
+			q := NewBoundedWaitQueue[int](capacity)
+			benchmarkProducerConsumer(b, producers, func(v int) { _ = q.Push(v) }, func() int { _, _ = q.Pop(); return 1 })
+		})
+	}
+}
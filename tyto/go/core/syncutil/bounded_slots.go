@@ -0,0 +1,87 @@
+package syncutil
+
+import (
+	"sync"
+	"time"
+)
+
+// 有界队列的空位，每个元素入队前占用一个空位，出队后释放
+type boundedSlots struct {
+	slots     chan struct{}
+	done      chan struct{} // 关闭后唤醒所有等待空位的生产者
+	closeOnce sync.Once
+}
+
+func newBoundedSlots(capacity int32) boundedSlots {
+	if capacity <= 0 {
+		panic("capacity of bounded queue must be greater than 0")
+	}
+
+	return boundedSlots{
+		slots:     make(chan struct{}, capacity),
+		done:      make(chan struct{}),
+		closeOnce: sync.Once{},
+	}
+}
+
+// 等待空位，已关闭时返回ErrQueueClosed
+func (s *boundedSlots) acquire() error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-s.done:
+		return ErrQueueClosed
+	}
+}
+
+// 不等待，没有空位时返回ErrQueueFull
+func (s *boundedSlots) tryAcquire() error {
+	select {
+	case <-s.done:
+		return ErrQueueClosed
+	default:
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// 最多等待d，超时返回ErrQueueTimeout
+func (s *boundedSlots) acquireTimeout(d time.Duration) error {
+	if d <= 0 {
+		if err := s.tryAcquire(); err != ErrQueueFull {
+			return err
+		}
+		return ErrQueueTimeout
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-s.done:
+		return ErrQueueClosed
+	case <-timer.C:
+		return ErrQueueTimeout
+	}
+}
+
+func (s *boundedSlots) release() {
+	<-s.slots
+}
+
+func (s *boundedSlots) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *boundedSlots) capacity() int32 {
+	return int32(cap(s.slots))
+}
@@ -0,0 +1,175 @@
+package syncutil
+
+import (
+	"sync"
+	"time"
+	"tyto/core/memutil"
+)
+
+// 有界等待队列，适合多生产者多消费者场景
+// 队列已满时，Push阻塞等待空位；队列为空时，Pop阻塞等待元素
+type BoundedWaitQueue[T any] struct {
+	cond   *sync.Cond
+	queue  memutil.RingBuffer[T]
+	slots  boundedSlots
+	closed bool
+}
+
+func NewBoundedWaitQueue[T any](capacity int32) *BoundedWaitQueue[T] {
+	return &BoundedWaitQueue[T]{
+		cond:   sync.NewCond(&sync.Mutex{}),
+		queue:  *memutil.NewRingBuffer[T](capacity),
+		slots:  newBoundedSlots(capacity),
+		closed: false,
+	}
+}
+
+// 添加元素到队列尾部，队列已满时阻塞，已关闭时返回ErrQueueClosed
+func (q *BoundedWaitQueue[T]) Push(v T) error {
+	if err := q.slots.acquire(); err != nil {
+		return err
+	}
+
+	return q.push(v)
+}
+
+// 添加元素到队列尾部，队列已满时返回ErrQueueFull，已关闭时返回ErrQueueClosed
+func (q *BoundedWaitQueue[T]) TryPush(v T) error {
+	if err := q.slots.tryAcquire(); err != nil {
+		return err
+	}
+
+	return q.push(v)
+}
+
+// 添加元素到队列尾部，最多等待d，超时返回ErrQueueTimeout，已关闭时返回ErrQueueClosed
+func (q *BoundedWaitQueue[T]) PushTimeout(v T, d time.Duration) error {
+	if err := q.slots.acquireTimeout(d); err != nil {
+		return err
+	}
+
+	return q.push(v)
+}
+
+func (q *BoundedWaitQueue[T]) push(v T) error {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.closed {
+		q.slots.release()
+		return ErrQueueClosed
+	}
+
+	q.queue.Push(v)
+	q.cond.Signal()
+
+	return nil
+}
+
+// 弹出队列头部元素，队列为空时阻塞
+// 已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedWaitQueue[T]) Pop() (T, error) {
+	q.cond.L.Lock()
+
+	for q.queue.Empty() && !q.closed {
+		q.cond.Wait()
+	}
+
+	v, ok := q.queue.Pop()
+
+	q.cond.L.Unlock()
+
+	if !ok {
+		return v, ErrQueueClosed
+	}
+
+	q.slots.release()
+
+	return v, nil
+}
+
+// 弹出队列头部元素，不阻塞
+// 队列为空时返回ErrQueueEmpty，已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedWaitQueue[T]) TryPop() (T, error) {
+	q.cond.L.Lock()
+
+	v, ok := q.queue.Pop()
+	closed := q.closed
+
+	q.cond.L.Unlock()
+
+	if ok {
+		q.slots.release()
+		return v, nil
+	}
+
+	if closed {
+		return v, ErrQueueClosed
+	}
+
+	return v, ErrQueueEmpty
+}
+
+// 弹出最多len(vs)个元素，队列为空时阻塞
+// 已关闭且已取空时返回ErrQueueClosed
+func (q *BoundedWaitQueue[T]) PopSome(vs []T) (int32, error) {
+	q.cond.L.Lock()
+
+	for q.queue.Empty() && !q.closed {
+		q.cond.Wait()
+	}
+
+	count := q.queue.PopSome(vs)
+
+	q.cond.L.Unlock()
+
+	if count == 0 && len(vs) > 0 {
+		return 0, ErrQueueClosed
+	}
+
+	for i := int32(0); i < count; i++ {
+		q.slots.release()
+	}
+
+	return count, nil
+}
+
+// 关闭队列，之后的Push会失败，已有的元素仍然可以取出
+// 会唤醒所有等待中的生产者和消费者
+func (q *BoundedWaitQueue[T]) Close() {
+	q.cond.L.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.cond.L.Unlock()
+
+	q.slots.close()
+}
+
+// 是否已关闭
+func (q *BoundedWaitQueue[T]) IsClosed() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.closed
+}
+
+// 返回队列长度
+func (q *BoundedWaitQueue[T]) Len() int32 {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.queue.Len()
+}
+
+// 队列容量
+func (q *BoundedWaitQueue[T]) Cap() int32 {
+	return q.slots.capacity()
+}
+
+// 判断队列是否为空
+func (q *BoundedWaitQueue[T]) Empty() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.queue.Empty()
+}
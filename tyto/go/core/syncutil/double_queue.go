@@ -1,65 +1,233 @@
 package syncutil
 
 import (
-	"sync"
+	"context"
+	"sync/atomic"
+	"time"
 	"tyto/core/memutil"
 )
 
-// 双缓冲队列，适合多生产者单消费者场景，不要使用多个线程去调用Pop()
+// 双缓冲队列，适合多生产者单消费者场景，不要使用多个线程去调用Pop系列的方法
+// 关闭后不能再Push，但可以继续Pop，直到取空
 type DoubleQueue[T any] struct {
-	cond       *sync.Cond
+	lock       SpinLock
+	notify     chan struct{} // 消费者等待时，由生产者唤醒
+	waiting    bool          // 消费者是否正在等待
+	closed     bool
+	length     atomic.Int32 // 队列长度，Push时增加，Pop时减少，任意协程都可以读取
 	writeQueue *memutil.RingBuffer[T]
 	readQueue  *memutil.RingBuffer[T]
 }
 
 func NewDoubleQueue[T any](initCapacity int32) *DoubleQueue[T] {
 	return &DoubleQueue[T]{
-		cond:       sync.NewCond(&SpinLock{}),
+		lock:       SpinLock{},
+		notify:     make(chan struct{}, 1),
+		waiting:    false,
+		closed:     false,
+		length:     atomic.Int32{},
 		writeQueue: memutil.NewRingBuffer[T](initCapacity),
 		readQueue:  memutil.NewRingBuffer[T](initCapacity),
 	}
 }
 
-// 弹出队列头部元素
-// 不要使用多个线程去调用Pop()
+// 弹出队列头部元素，队列为空时阻塞
+// 队列已关闭且已取空时，返回零值
 func (q *DoubleQueue[T]) Pop() T {
-	if q.readQueue.Empty() {
-		q.cond.L.Lock()
-		for q.writeQueue.Empty() {
-			q.cond.Wait()
-		}
+	v, _ := q.pop(nil, nil)
+	return v
+}
+
+// 弹出队列头部元素，不阻塞
+// 队列为空时返回ErrQueueEmpty，已关闭且已取空时返回ErrQueueClosed
+func (q *DoubleQueue[T]) TryPop() (T, error) {
+	if v, ok := q.popRead(); ok {
+		return v, nil
+	}
 
+	q.lock.Lock()
+	closed := q.closed
+	if !q.writeQueue.Empty() {
 		q.readQueue, q.writeQueue = q.writeQueue, q.readQueue
+	}
+	q.lock.Unlock()
 
-		q.cond.L.Unlock()
+	if v, ok := q.popRead(); ok {
+		return v, nil
 	}
 
-	v, _ := q.readQueue.Pop()
+	var zero T
+	if closed {
+		return zero, ErrQueueClosed
+	}
 
-	return v
+	return zero, ErrQueueEmpty
+}
+
+// 弹出队列头部元素，最多等待d
+// 超时返回ErrQueueTimeout，已关闭且已取空时返回ErrQueueClosed
+func (q *DoubleQueue[T]) PopTimeout(d time.Duration) (T, error) {
+	if d <= 0 {
+		v, err := q.TryPop()
+		if err == ErrQueueEmpty {
+			err = ErrQueueTimeout
+		}
+		return v, err
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	return q.pop(timer.C, nil)
+}
+
+// 弹出队列头部元素，等待直到ctx结束
+// ctx结束时返回ctx.Err()，已关闭且已取空时返回ErrQueueClosed
+func (q *DoubleQueue[T]) PopContext(ctx context.Context) (T, error) {
+	return q.pop(nil, ctx)
+}
+
+// 弹出队列中的所有元素，追加到dst后返回，队列为空时阻塞
+// 已关闭且已取空时返回ErrQueueClosed
+func (q *DoubleQueue[T]) PopAll(dst []T) ([]T, error) {
+	v, err := q.pop(nil, nil)
+	if err != nil {
+		return dst, err
+	}
+
+	dst = append(dst, v)
+	for {
+		v, err := q.TryPop()
+		if err != nil {
+			return dst, nil
+		}
+
+		dst = append(dst, v)
+	}
+}
+
+func (q *DoubleQueue[T]) pop(timeout <-chan time.Time, ctx context.Context) (T, error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	for {
+		if v, ok := q.popRead(); ok {
+			return v, nil
+		}
+
+		q.lock.Lock()
+		if !q.writeQueue.Empty() {
+			q.readQueue, q.writeQueue = q.writeQueue, q.readQueue
+			q.waiting = false
+			q.lock.Unlock()
+			continue
+		}
+
+		if q.closed {
+			q.lock.Unlock()
+
+			var zero T
+			return zero, ErrQueueClosed
+		}
+
+		q.waiting = true
+		q.lock.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-timeout:
+			var zero T
+			return zero, ErrQueueTimeout
+		case <-done:
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// 从读队列弹出元素，只由消费者调用，readQueue不需要加锁
+func (q *DoubleQueue[T]) popRead() (T, bool) {
+	v, ok := q.readQueue.Pop()
+	if ok {
+		q.length.Add(-1)
+	}
+
+	return v, ok
+}
+
+// 唤醒等待中的消费者，需要在锁内调用
+func (q *DoubleQueue[T]) wakeup() {
+	if !q.waiting {
+		return
+	}
+
+	q.waiting = false
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
 }
 
-// 添加元素到队列尾部
-func (q *DoubleQueue[T]) Push(v T) {
-	q.cond.L.Lock()
+// 添加元素到队列尾部，已关闭时返回ErrQueueClosed
+func (q *DoubleQueue[T]) Push(v T) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
 	q.writeQueue.Push(v)
-	q.cond.Signal()
-	q.cond.L.Unlock()
+	q.length.Add(1)
+	q.wakeup()
+
+	return nil
 }
 
-func (q *DoubleQueue[T]) PushAll(vs []T) {
-	q.cond.L.Lock()
+// 添加多个元素到队列尾部，已关闭时返回ErrQueueClosed
+func (q *DoubleQueue[T]) PushAll(vs []T) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
 	q.writeQueue.PushAll(vs)
-	q.cond.Signal()
-	q.cond.L.Unlock()
+	q.length.Add(int32(len(vs)))
+	q.wakeup()
+
+	return nil
 }
 
-// 队列长度
-func (q *DoubleQueue[T]) Len() int32 {
-	q.cond.L.Lock()
-	defer q.cond.L.Unlock()
+// 关闭队列，之后的Push会失败，已有的元素仍然可以取出
+// 会唤醒等待中的消费者
+func (q *DoubleQueue[T]) Close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
 
-	return q.writeQueue.Len() + q.readQueue.Len()
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	q.wakeup()
+}
+
+// 是否已关闭
+func (q *DoubleQueue[T]) IsClosed() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.closed
+}
+
+// 队列长度，不加锁，可以与消费者并发调用
+func (q *DoubleQueue[T]) Len() int32 {
+	return q.length.Load()
 }
 
 func (q *DoubleQueue[T]) Empty() bool {
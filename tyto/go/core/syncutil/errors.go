@@ -0,0 +1,10 @@
+package syncutil
+
+import "errors"
+
+var (
+	ErrQueueClosed  = errors.New("queue closed")  // 队列已关闭，Pop时表示队列已关闭且已取空
+	ErrQueueEmpty   = errors.New("queue empty")   // 队列为空
+	ErrQueueFull    = errors.New("queue full")    // 有界队列已满
+	ErrQueueTimeout = errors.New("queue timeout") // 等待超时
+)
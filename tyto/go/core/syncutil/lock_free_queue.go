@@ -0,0 +1,328 @@
+package syncutil
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// 每个分段的元素数量
+const LOCK_FREE_SEGMENT_SIZE = 256
+
+// 关闭时加到最后一个分段的claimed上，之后占用的序号都超出分段大小
+const LOCK_FREE_CLOSED_OFFSET = 1 << 30
+
+// 分段中的一个元素
+type lockFreeSlot[T any] struct {
+	value T
+	ready atomic.Bool // 生产者已写入value
+}
+
+// 分段，生产者通过原子递增claimed占用元素，写满后链接下一个分段
+// 关闭时next指向自身，不能再链接新的分段
+type lockFreeSegment[T any] struct {
+	slots    [LOCK_FREE_SEGMENT_SIZE]lockFreeSlot[T]
+	claimed  atomic.Int32 // 已被生产者占用的元素数量，可能超过分段大小
+	closedAt atomic.Int32 // 关闭时已占用的元素数量，-1表示未关闭
+	next     atomic.Pointer[lockFreeSegment[T]]
+	base     int64 // 第一个元素在整个队列中的序号，用于计算队列长度
+}
+
+func newLockFreeSegment[T any](base int64) *lockFreeSegment[T] {
+	segment := &lockFreeSegment[T]{base: base}
+	segment.closedAt.Store(-1)
+	return segment
+}
+
+// 已被生产者占用的有效元素数量，不超过分段大小
+func (segment *lockFreeSegment[T]) claimedCount() int32 {
+	claimed := segment.claimed.Load()
+	if claimed < LOCK_FREE_CLOSED_OFFSET {
+		return min(claimed, LOCK_FREE_SEGMENT_SIZE)
+	}
+
+	// 已关闭，等待Close记录关闭时的数量
+	for {
+		if closedAt := segment.closedAt.Load(); closedAt >= 0 {
+			return closedAt
+		}
+		runtime.Gosched()
+	}
+}
+
+// 无锁的多生产者单消费者队列，不要使用多个线程去调用Pop系列的方法
+// 每次Push只对分段的claimed做一次原子递增，其余都是读取或写入各自占用的元素，生产者之间不会互相阻塞
+// 队列为空时，消费者休眠等待，由生产者唤醒
+// 关闭后不能再Push，但可以继续Pop，直到取空
+type LockFreeQueue[T any] struct {
+	tail      atomic.Pointer[lockFreeSegment[T]] // 生产者写入的分段
+	head      *lockFreeSegment[T]                // 消费者读取的分段，只由消费者访问
+	readIndex int32                              // 消费者在head中的读取位置
+	popped    atomic.Int64                       // 已取出的元素数量，只由消费者修改，避免生产者竞争同一个计数器
+	parked    atomic.Bool                        // 消费者是否正在休眠
+	notify    chan struct{}                      // 唤醒消费者
+	closed    atomic.Bool
+}
+
+func NewLockFreeQueue[T any]() *LockFreeQueue[T] {
+	segment := newLockFreeSegment[T](0)
+
+	q := &LockFreeQueue[T]{
+		head:      segment,
+		readIndex: 0,
+		notify:    make(chan struct{}, 1),
+	}
+	q.tail.Store(segment)
+
+	return q
+}
+
+// 添加元素到队列尾部，已关闭时返回ErrQueueClosed
+func (q *LockFreeQueue[T]) Push(v T) error {
+	if q.closed.Load() {
+		return ErrQueueClosed
+	}
+
+	for {
+		segment := q.tail.Load()
+		index := segment.claimed.Add(1) - 1
+
+		if index < LOCK_FREE_SEGMENT_SIZE {
+			slot := &segment.slots[index]
+			slot.value = v
+			slot.ready.Store(true)
+			break
+		}
+
+		// 分段已写满或已关闭，链接新的分段，失败说明其他生产者已经链接，或者队列已关闭
+		next := segment.next.Load()
+		if next == nil {
+			next = newLockFreeSegment[T](segment.base + LOCK_FREE_SEGMENT_SIZE)
+			if !segment.next.CompareAndSwap(nil, next) {
+				next = segment.next.Load()
+			}
+		}
+
+		if next == segment {
+			return ErrQueueClosed
+		}
+
+		q.tail.CompareAndSwap(segment, next)
+	}
+
+	q.wakeup()
+
+	return nil
+}
+
+// 唤醒休眠中的消费者
+func (q *LockFreeQueue[T]) wakeup() {
+	if !q.parked.Load() || !q.parked.CompareAndSwap(true, false) {
+		return
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// 取出一个元素，队列为空时返回false
+func (q *LockFreeQueue[T]) tryPop() (T, bool) {
+	for {
+		segment := q.head
+
+		if q.readIndex == LOCK_FREE_SEGMENT_SIZE {
+			// 分段已读完，等待生产者链接下一个分段
+			next := segment.next.Load()
+			if next == nil || next == segment {
+				var zero T
+				return zero, false
+			}
+
+			q.head = next
+			q.readIndex = 0
+			continue
+		}
+
+		slot := &segment.slots[q.readIndex]
+		for !slot.ready.Load() {
+			if segment.claimedCount() <= q.readIndex {
+				var zero T
+				return zero, false
+			}
+
+			// 生产者已占用，但还未写入，稍等即可
+			runtime.Gosched()
+		}
+
+		v := slot.value
+
+		var zero T
+		slot.value = zero
+		q.readIndex++
+		q.popped.Store(q.popped.Load() + 1)
+
+		return v, true
+	}
+}
+
+// 是否有已占用的元素，生产者可能还未写入
+func (q *LockFreeQueue[T]) hasClaimed() bool {
+	if q.readIndex < q.head.claimedCount() {
+		return true
+	}
+
+	next := q.head.next.Load()
+	return next != nil && next != q.head
+}
+
+// 队列是否已关闭且已取空，需要在tryPop失败后调用
+// Close封闭最后一个分段后，不会再有新的元素
+func (q *LockFreeQueue[T]) isDrained() bool {
+	return q.head.next.Load() == q.head && !q.hasClaimed()
+}
+
+// 弹出队列头部元素，队列为空时阻塞
+// 队列已关闭且已取空时，返回零值
+func (q *LockFreeQueue[T]) Pop() T {
+	v, _ := q.pop(nil, nil)
+	return v
+}
+
+// 弹出队列头部元素，不阻塞
+// 队列为空时返回ErrQueueEmpty，已关闭且已取空时返回ErrQueueClosed
+func (q *LockFreeQueue[T]) TryPop() (T, error) {
+	if v, ok := q.tryPop(); ok {
+		return v, nil
+	}
+
+	var zero T
+	if q.isDrained() {
+		return zero, ErrQueueClosed
+	}
+
+	return zero, ErrQueueEmpty
+}
+
+// 弹出队列头部元素，最多等待d
+// 超时返回ErrQueueTimeout，已关闭且已取空时返回ErrQueueClosed
+func (q *LockFreeQueue[T]) PopTimeout(d time.Duration) (T, error) {
+	if d <= 0 {
+		v, err := q.TryPop()
+		if err == ErrQueueEmpty {
+			err = ErrQueueTimeout
+		}
+		return v, err
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	return q.pop(timer.C, nil)
+}
+
+// 弹出队列头部元素，等待直到ctx结束
+// ctx结束时返回ctx.Err()，已关闭且已取空时返回ErrQueueClosed
+func (q *LockFreeQueue[T]) PopContext(ctx context.Context) (T, error) {
+	return q.pop(nil, ctx)
+}
+
+// 弹出队列中的所有元素，追加到dst后返回，队列为空时阻塞
+// 已关闭且已取空时返回ErrQueueClosed
+func (q *LockFreeQueue[T]) PopAll(dst []T) ([]T, error) {
+	v, err := q.pop(nil, nil)
+	if err != nil {
+		return dst, err
+	}
+
+	dst = append(dst, v)
+	for {
+		v, ok := q.tryPop()
+		if !ok {
+			return dst, nil
+		}
+
+		dst = append(dst, v)
+	}
+}
+
+func (q *LockFreeQueue[T]) pop(timeout <-chan time.Time, ctx context.Context) (T, error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	for {
+		if v, ok := q.tryPop(); ok {
+			return v, nil
+		}
+
+		if q.isDrained() {
+			var zero T
+			return zero, ErrQueueClosed
+		}
+
+		// 先标记休眠，再检查一次，避免错过生产者的唤醒
+		q.parked.Store(true)
+		if q.hasClaimed() || q.isDrained() {
+			q.parked.Store(false)
+			continue
+		}
+
+		select {
+		case <-q.notify:
+		case <-timeout:
+			q.parked.Store(false)
+			var zero T
+			return zero, ErrQueueTimeout
+		case <-done:
+			q.parked.Store(false)
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// 关闭队列，之后的Push会失败，已有的元素仍然可以取出
+// 会唤醒等待中的消费者
+func (q *LockFreeQueue[T]) Close() {
+	if !q.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	// 封闭最后一个分段，已经通过关闭检查的生产者无法再链接新的分段，也无法占用该分段剩余的元素
+	for {
+		segment := q.tail.Load()
+		if segment.next.CompareAndSwap(nil, segment) {
+			claimed := segment.claimed.Add(LOCK_FREE_CLOSED_OFFSET) - LOCK_FREE_CLOSED_OFFSET
+			segment.closedAt.Store(min(claimed, LOCK_FREE_SEGMENT_SIZE))
+			break
+		}
+
+		q.tail.CompareAndSwap(segment, segment.next.Load())
+	}
+
+	q.wakeup()
+}
+
+// 是否已关闭
+func (q *LockFreeQueue[T]) IsClosed() bool {
+	return q.closed.Load()
+}
+
+// 队列长度，包括已占用但还未写入的元素，并发修改时只是近似值
+func (q *LockFreeQueue[T]) Len() int32 {
+	tail := q.tail.Load()
+	length := tail.base + int64(tail.claimedCount()) - q.popped.Load()
+	if length < 0 {
+		return 0
+	}
+
+	return int32(length)
+}
+
+func (q *LockFreeQueue[T]) Empty() bool {
+	return q.Len() == 0
+}
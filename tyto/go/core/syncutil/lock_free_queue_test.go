@@ -0,0 +1,140 @@
+package syncutil
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func forEachMpscQueue(t *testing.T, f func(t *testing.T, q MpscQueue[int])) {
+	t.Run("LockFreeQueue", func(t *testing.T) { f(t, NewLockFreeQueue[int]()) })
+	t.Run("DoubleQueue", func(t *testing.T) { f(t, NewDoubleQueue[int](16)) })
+}
+
+// 多个生产者并发写入，消费者取出的元素不丢失、不重复，且每个生产者的元素保持写入顺序
+func TestMpscQueueOrdering(t *testing.T) {
+	const (
+		producers = 64
+		count     = 2000 // 每个生产者写入的元素数量，跨越多个分段
+	)
+
+	forEachMpscQueue(t, func(t *testing.T, q MpscQueue[int]) {
+		var wg sync.WaitGroup
+		for p := 0; p < producers; p++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < count; i++ {
+					if err := q.Push(p*count + i); err != nil {
+						t.Error("Push failed:", err)
+						return
+					}
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			q.Close()
+		}()
+
+		// 每个生产者下一个期望的序号
+		next := make([]int, producers)
+		total := 0
+
+		var vs []int
+		for {
+			var err error
+			vs, err = q.PopAll(vs[:0])
+			if err == ErrQueueClosed {
+				break
+			}
+			if err != nil {
+				t.Fatal("PopAll failed:", err)
+			}
+
+			for _, v := range vs {
+				p, i := v/count, v%count
+				if i != next[p] {
+					t.Fatalf("producer %d: got %d, want %d", p, i, next[p])
+				}
+				next[p]++
+			}
+			total += len(vs)
+		}
+
+		if total != producers*count {
+			t.Fatalf("popped %d, want %d", total, producers*count)
+		}
+		if n := q.Len(); n != 0 {
+			t.Errorf("Len = %d after drained, want 0", n)
+		}
+		if err := q.Push(0); err != ErrQueueClosed {
+			t.Errorf("Push after close = %v, want ErrQueueClosed", err)
+		}
+	})
+}
+
+// 关闭时仍有生产者在写入，成功Push的元素都能取出，失败的不会出现
+func TestMpscQueueCloseWhilePushing(t *testing.T) {
+	const producers = 16
+
+	for round := 0; round < 20; round++ {
+		forEachMpscQueue(t, func(t *testing.T, q MpscQueue[int]) {
+			var (
+				wg     sync.WaitGroup
+				pushed atomic.Int64
+			)
+			for p := 0; p < producers; p++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 10000 && q.Push(i) == nil; i++ {
+						pushed.Add(1)
+					}
+				}()
+			}
+
+			time.Sleep(time.Millisecond)
+			q.Close()
+
+			popped := int64(0)
+			var vs []int
+			for {
+				var err error
+				if vs, err = q.PopAll(vs[:0]); err != nil {
+					break
+				}
+				popped += int64(len(vs))
+			}
+
+			wg.Wait()
+			if popped != pushed.Load() {
+				t.Fatalf("popped %d, pushed %d", popped, pushed.Load())
+			}
+		})
+	}
+}
+
+func BenchmarkMpscQueue(b *testing.B) {
+	for _, producers := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("LockFreeQueue/producers=%d", producers), func(b *testing.B) {
+			benchmarkMpscQueue(b, NewLockFreeQueue[int](), producers)
+		})
+
+		b.Run(fmt.Sprintf("DoubleQueue/producers=%d", producers), func(b *testing.B) {
+			benchmarkMpscQueue(b, NewDoubleQueue[int](1024), producers)
+		})
+	}
+}
+
+// 消费者使用PopAll批量取出
+func benchmarkMpscQueue(b *testing.B, q MpscQueue[int], producers int) {
+	var vs []int
+	benchmarkProducerConsumer(b, producers, func(v int) { _ = q.Push(v) }, func() int {
+		vs, _ = q.PopAll(vs[:0])
+		return len(vs)
+	})
+}
@@ -0,0 +1,275 @@
+package syncutil
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// 可以尝试加锁的sync.Locker
+type tryLocker interface {
+	sync.Locker
+	TryLock() bool
+}
+
+func forEachLock(t *testing.T, f func(t *testing.T, lock tryLocker)) {
+	t.Run("SpinLock", func(t *testing.T) { f(t, &SpinLock{}) })
+	t.Run("AdaptiveLock", func(t *testing.T) { f(t, &AdaptiveLock{}) })
+	t.Run("RWSpinLock", func(t *testing.T) { f(t, &RWSpinLock{}) })
+}
+
+func mustPanic(t *testing.T, what string, f func()) {
+	t.Helper()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s should panic", what)
+		}
+	}()
+
+	f()
+}
+
+func TestLockTryLock(t *testing.T) {
+	forEachLock(t, func(t *testing.T, lock tryLocker) {
+		if !lock.TryLock() {
+			t.Fatal("TryLock on unlocked lock failed")
+		}
+		if lock.TryLock() {
+			t.Fatal("TryLock on locked lock succeeded")
+		}
+
+		lock.Unlock()
+		if !lock.TryLock() {
+			t.Fatal("TryLock after Unlock failed")
+		}
+		lock.Unlock()
+	})
+}
+
+func TestLockUnlockOfUnlocked(t *testing.T) {
+	forEachLock(t, func(t *testing.T, lock tryLocker) {
+		mustPanic(t, "Unlock of unlocked lock", lock.Unlock)
+
+		// panic后锁仍然可用
+		lock.Lock()
+		lock.Unlock()
+	})
+}
+
+// 多个协程并发修改同一个计数器，加锁后结果正确
+func TestLockMutualExclusion(t *testing.T) {
+	const (
+		goroutines = 8
+		count      = 10000
+	)
+
+	forEachLock(t, func(t *testing.T, lock tryLocker) {
+		counter := 0
+
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < count; i++ {
+					lock.Lock()
+					counter++
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if counter != goroutines*count {
+			t.Fatalf("counter = %d, want %d", counter, goroutines*count)
+		}
+	})
+}
+
+func TestRWSpinLockReadersAndWriter(t *testing.T) {
+	var lock RWSpinLock
+
+	// 多个读者可以同时持有读锁，此时不能加写锁
+	lock.RLock()
+	if !lock.TryRLock() {
+		t.Fatal("second reader failed")
+	}
+	if lock.TryLock() {
+		t.Fatal("TryLock succeeded while readers hold the lock")
+	}
+
+	lock.RUnlock()
+	lock.RUnlock()
+
+	// 持有写锁时，读者不能加锁
+	lock.Lock()
+	if lock.TryRLock() {
+		t.Fatal("TryRLock succeeded while writer holds the lock")
+	}
+	lock.Unlock()
+
+	if !lock.TryRLock() {
+		t.Fatal("TryRLock after Unlock failed")
+	}
+	lock.RUnlock()
+}
+
+func TestRWSpinLockRUnlockOfUnlocked(t *testing.T) {
+	var lock RWSpinLock
+
+	mustPanic(t, "RUnlock of unlocked lock", lock.RUnlock)
+	if state := lock.state.Load(); state != 0 {
+		t.Fatalf("state = %d after bad RUnlock, want 0", state)
+	}
+
+	// 持有写锁时错误的RUnlock不能释放写锁
+	lock.Lock()
+	mustPanic(t, "RUnlock of write-locked lock", lock.RUnlock)
+	if state := lock.state.Load(); state != RW_SPIN_WRITER_LOCKED {
+		t.Fatalf("state = %d after bad RUnlock, want RW_SPIN_WRITER_LOCKED", state)
+	}
+	lock.Unlock()
+
+	lock.RLock()
+	lock.RUnlock()
+}
+
+// 写者持有锁时不会有读者，读者持有锁时不会有写者
+func TestRWSpinLockConcurrent(t *testing.T) {
+	const (
+		readers = 8
+		writers = 2
+		count   = 5000
+	)
+
+	var (
+		lock    RWSpinLock
+		reading atomic.Int32
+		writing atomic.Int32
+		wg      sync.WaitGroup
+	)
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				lock.RLock()
+				reading.Add(1)
+				if writing.Load() != 0 {
+					t.Error("reader and writer hold the lock at the same time")
+				}
+				reading.Add(-1)
+				lock.RUnlock()
+			}
+		}()
+	}
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				lock.Lock()
+				if writing.Add(1) != 1 || reading.Load() != 0 {
+					t.Error("writer does not hold the lock exclusively")
+				}
+				writing.Add(-1)
+				lock.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// 临界区内的工作量，模拟很短的临界区
+func criticalSection(v *int) {
+	for i := 0; i < 16; i++ {
+		*v += i
+	}
+}
+
+func BenchmarkLock(b *testing.B) {
+	locks := []struct {
+		name    string
+		newLock func() sync.Locker
+	}{
+		{"sync.Mutex", func() sync.Locker { return &sync.Mutex{} }},
+		{"SpinLock", func() sync.Locker { return &SpinLock{} }},
+		{"AdaptiveLock", func() sync.Locker { return &AdaptiveLock{} }},
+	}
+
+	for _, l := range locks {
+		b.Run(l.name+"/uncontended", func(b *testing.B) {
+			lock := l.newLock()
+			v := 0
+			for i := 0; i < b.N; i++ {
+				lock.Lock()
+				criticalSection(&v)
+				lock.Unlock()
+			}
+		})
+
+		for _, goroutines := range []int{8, 64} {
+			b.Run(fmt.Sprintf("%s/contended=%d", l.name, goroutines), func(b *testing.B) {
+				lock := l.newLock()
+				v := 0
+				b.SetParallelism(max(1, goroutines/runtime.GOMAXPROCS(0)))
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						lock.Lock()
+						criticalSection(&v)
+						lock.Unlock()
+					}
+				})
+			})
+		}
+	}
+}
+
+// 与sync.RWMutex比较，readPercent为读操作的百分比
+func BenchmarkRWLock(b *testing.B) {
+	type rwLocker interface {
+		sync.Locker
+		RLock()
+		RUnlock()
+	}
+
+	locks := []struct {
+		name    string
+		newLock func() rwLocker
+	}{
+		{"sync.RWMutex", func() rwLocker { return &sync.RWMutex{} }},
+		{"RWSpinLock", func() rwLocker { return &RWSpinLock{} }},
+	}
+
+	for _, l := range locks {
+		for _, readPercent := range []int{100, 99, 90, 50} {
+			b.Run(fmt.Sprintf("%s/read=%d%%", l.name, readPercent), func(b *testing.B) {
+				lock := l.newLock()
+				v := 0
+				b.SetParallelism(max(1, 8/runtime.GOMAXPROCS(0)))
+				b.RunParallel(func(pb *testing.PB) {
+					sum, i := 0, 0
+					for pb.Next() {
+						if i%100 < readPercent {
+							lock.RLock()
+							sum += v
+							lock.RUnlock()
+						} else {
+							lock.Lock()
+							criticalSection(&v)
+							lock.Unlock()
+						}
+						i++
+					}
+					_ = sum
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package syncutil
+
+import (
+	"context"
+	"time"
+)
+
+// 多生产者单消费者队列，DoubleQueue和LockFreeQueue都实现了该接口
+// 不要使用多个线程去调用Pop系列的方法
+type MpscQueue[T any] interface {
+	// 添加元素到队列尾部，已关闭时返回ErrQueueClosed
+	Push(v T) error
+	// 弹出队列头部元素，队列为空时阻塞，已关闭且已取空时返回零值
+	Pop() T
+	// 弹出队列头部元素，不阻塞
+	TryPop() (T, error)
+	// 弹出队列头部元素，最多等待d
+	PopTimeout(d time.Duration) (T, error)
+	// 弹出队列头部元素，等待直到ctx结束
+	PopContext(ctx context.Context) (T, error)
+	// 弹出队列中的所有元素，追加到dst后返回，队列为空时阻塞
+	PopAll(dst []T) ([]T, error)
+	// 关闭队列，之后的Push会失败，已有的元素仍然可以取出
+	Close()
+	IsClosed() bool
+	Len() int32
+	Empty() bool
+}
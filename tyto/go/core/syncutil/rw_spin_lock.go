@@ -0,0 +1,109 @@
+package syncutil
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// 已加写锁时的状态，非负数为读者数量
+const RW_SPIN_WRITER_LOCKED = -1
+
+var _ sync.Locker = (*RWSpinLock)(nil)
+
+// 读写自旋锁，零值可用，不可重入
+// 适合读多写少、临界区很短的场景，如配置表、敏感词表等快照的读取与替换
+// 写者优先：有写者等待时，新的读者会等待，避免写者饥饿
+type RWSpinLock struct {
+	state   atomic.Int32 // 读者数量，或RW_SPIN_WRITER_LOCKED
+	writers atomic.Int32 // 正在等待的写者数量
+}
+
+// 加读锁
+func (lock *RWSpinLock) RLock() {
+	conflictCount := 0
+
+	for !lock.TryRLock() {
+		for lock.writers.Load() > 0 || lock.state.Load() < 0 {
+			runtime.Gosched()
+		}
+
+		backoff(conflictCount)
+		conflictCount++
+	}
+}
+
+// 尝试加读锁，成功返回true，不会阻塞
+func (lock *RWSpinLock) TryRLock() bool {
+	for lock.writers.Load() == 0 {
+		state := lock.state.Load()
+		if state < 0 {
+			return false
+		}
+
+		// 只与其他读者冲突，重试即可
+		if lock.state.CompareAndSwap(state, state+1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 解读锁，未加读锁时panic
+func (lock *RWSpinLock) RUnlock() {
+	// 先检查再修改，未加读锁时不能改变状态，否则会破坏写锁或使计数变为负数
+	for {
+		state := lock.state.Load()
+		if state <= 0 {
+			panic("syncutil: RUnlock of unlocked RWSpinLock")
+		}
+
+		if lock.state.CompareAndSwap(state, state-1) {
+			return
+		}
+	}
+}
+
+// 加写锁
+func (lock *RWSpinLock) Lock() {
+	lock.writers.Add(1)
+
+	conflictCount := 0
+	for {
+		for lock.state.Load() != 0 {
+			runtime.Gosched()
+		}
+
+		if lock.state.CompareAndSwap(0, RW_SPIN_WRITER_LOCKED) {
+			break
+		}
+
+		backoff(conflictCount)
+		conflictCount++
+	}
+
+	lock.writers.Add(-1)
+}
+
+// 尝试加写锁，成功返回true，不会阻塞
+func (lock *RWSpinLock) TryLock() bool {
+	return lock.state.CompareAndSwap(0, RW_SPIN_WRITER_LOCKED)
+}
+
+// 解写锁，未加写锁时panic
+func (lock *RWSpinLock) Unlock() {
+	if !lock.state.CompareAndSwap(RW_SPIN_WRITER_LOCKED, 0) {
+		panic("syncutil: unlock of unlocked RWSpinLock")
+	}
+}
+
+// 返回使用读锁的sync.Locker
+func (lock *RWSpinLock) RLocker() sync.Locker {
+	return (*rwSpinLocker)(lock)
+}
+
+type rwSpinLocker RWSpinLock
+
+func (l *rwSpinLocker) Lock()   { (*RWSpinLock)(l).RLock() }
+func (l *rwSpinLocker) Unlock() { (*RWSpinLock)(l).RUnlock() }
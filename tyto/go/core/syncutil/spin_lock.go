@@ -3,13 +3,17 @@ package syncutil
 import (
 	"math/rand/v2"
 	"runtime"
+	"sync"
 	"sync/atomic"
 )
 
 // 对退避计算时，最大的偏移值
 const MAX_BACKOFF_SHIFT = 8
 
-// 自旋锁
+var _ sync.Locker = (*SpinLock)(nil)
+
+// 自旋锁，零值可用，不可重入
+// 临界区很短且冲突不多时比sync.Mutex快，临界区较长时应使用AdaptiveLock或sync.Mutex
 type SpinLock struct {
 	flag atomic.Bool
 }
@@ -24,34 +28,37 @@ func (lock *SpinLock) Lock() {
 			runtime.Gosched()
 		}
 
-		if !lock.flag.CompareAndSwap(false, true) {
-			shift := conflictCount
-			if conflictCount > MAX_BACKOFF_SHIFT {
-				shift = MAX_BACKOFF_SHIFT
-			}
-
-			conflictCount++
-
-			// 退避
-			relaxCount := rand.IntN(1 << shift)
-			for i := 0; i < relaxCount; i++ {
-				runtime.Gosched()
-			}
-			continue
-
-		} else {
+		if lock.flag.CompareAndSwap(false, true) {
 			// 加锁成功
 			break
 		}
+
+		backoff(conflictCount)
+		conflictCount++
 	}
 }
 
-// 尝试加锁
-func (lock *SpinLock) TryLock() {
-	lock.flag.CompareAndSwap(false, true)
+// 尝试加锁，成功返回true，不会阻塞
+func (lock *SpinLock) TryLock() bool {
+	return lock.flag.CompareAndSwap(false, true)
 }
 
-// 解锁
+// 解锁，与sync.Mutex相同，未加锁时panic
 func (lock *SpinLock) Unlock() {
-	lock.flag.Store(false)
+	if !lock.flag.CompareAndSwap(true, false) {
+		panic("syncutil: unlock of unlocked SpinLock")
+	}
+}
+
+// 冲突后随机退避，冲突次数越多，退避的上限越大
+func backoff(conflictCount int) {
+	shift := conflictCount
+	if conflictCount > MAX_BACKOFF_SHIFT {
+		shift = MAX_BACKOFF_SHIFT
+	}
+
+	relaxCount := rand.IntN(1 << shift)
+	for i := 0; i < relaxCount; i++ {
+		runtime.Gosched()
+	}
 }
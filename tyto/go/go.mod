@@ -10,9 +10,7 @@ require (
 	github.com/itchyny/timefmt-go v0.1.5
 	github.com/sevlyar/go-daemon v0.1.6
 	golang.org/x/exp v0.0.0-20240416160154-fe59bbe5cc7f
+	golang.org/x/sys v0.19.0
 )
 
-require (
-	github.com/kardianos/osext v0.0.0-20190222173326-2bc1f35cddc0 // indirect
-	golang.org/x/sys v0.19.0 // indirect
-)
+require github.com/kardianos/osext v0.0.0-20190222173326-2bc1f35cddc0 // indirect